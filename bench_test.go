@@ -0,0 +1,203 @@
+package projson
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// Benchmarks print documents of different shapes in each style, with and
+// without colors, next to encoding/json on the same data:
+//
+//	go test -run NONE -bench Print
+//
+// Each op prints a whole document.
+
+var benchDocs = []struct {
+	name string
+	doc  interface{}
+}{
+	{"records", benchRecords(200)},
+	{"deep", benchDeep(64)},
+	{"wide", benchWide(10000)},
+	{"longstrings", benchLongStrings(16, 4096)},
+}
+
+var benchStyles = []struct {
+	name  string
+	style int
+}{
+	{"simple", SimpleStyle},
+	{"smart", SmartStyle},
+	{"pretty", PrettyStyle},
+	{"hybrid", HybridStyle},
+}
+
+func benchRecords(n int) interface{} {
+	records := make([]interface{}, n)
+	for i := range records {
+		records[i] = map[string]interface{}{
+			"id":     i,
+			"name":   "user" + strconv.Itoa(i),
+			"score":  float64(i) * 1.25,
+			"active": i%3 == 0,
+			"tags":   []interface{}{"a", "bc", "def"},
+			"note":   nil,
+		}
+	}
+	return records
+}
+
+func benchDeep(depth int) interface{} {
+	var doc interface{} = depth
+	for i := 0; i < depth; i++ {
+		if i%2 == 0 {
+			doc = []interface{}{i, doc}
+		} else {
+			doc = map[string]interface{}{"level": i, "next": doc}
+		}
+	}
+	return doc
+}
+
+func benchWide(n int) interface{} {
+	arr := make([]interface{}, n)
+	for i := range arr {
+		arr[i] = i * 7919 % 100003
+	}
+	return arr
+}
+
+func benchLongStrings(n int, length int) interface{} {
+	const chunk = "lorem ipsum \"dolor\"\tsit amet, 日本語 <b>&</b>\n"
+	s := strings.Repeat(chunk, length/len(chunk)+1)[:length]
+
+	arr := make([]interface{}, n)
+	for i := range arr {
+		arr[i] = s
+	}
+	return arr
+}
+
+// putValue puts v, taking object members in the order of their keys as
+// encoding/json does.
+func putValue(jp *JsonPrinter, v interface{}) {
+	switch v := v.(type) {
+	case []interface{}:
+		jp.BeginArray()
+		for _, elem := range v {
+			putValue(jp, elem)
+		}
+		jp.FinishArray()
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		jp.BeginObject()
+		for _, key := range keys {
+			jp.PutKey(key)
+			putValue(jp, v[key])
+		}
+		jp.FinishObject()
+	case int:
+		jp.PutInt(v)
+	case float64:
+		jp.PutFloat(v)
+	case string:
+		jp.PutString(v)
+	case bool:
+		jp.PutBool(v)
+	case nil:
+		jp.PutNull()
+	}
+}
+
+// rewind makes jp ready to print another document with the same
+// settings, so that benchmarks do not measure NewPrinter.
+func rewind(jp *JsonPrinter) {
+	jp.state = stateInit
+	jp.pathStack = jp.pathStack[:0]
+	jp.buffer.Reset()
+	jp.docs = 0
+	jp.linepos = 0
+}
+
+func TestBenchDocs(t *testing.T) {
+	for _, d := range benchDocs {
+		expected, _ := json.Marshal(d.doc)
+
+		jp := NewPrinter()
+		putValue(jp, d.doc)
+		rewind(jp)
+		putValue(jp, d.doc)
+
+		if actual, err := jp.String(); err != nil || string(expected) != actual {
+			t.Errorf("%s\nexpected: %v\nactual: %v (%v)", d.name, string(expected), actual, err)
+		}
+	}
+}
+
+func BenchmarkPrint(b *testing.B) {
+	for _, d := range benchDocs {
+		for _, s := range benchStyles {
+			for _, color := range []bool{false, true} {
+				name := d.name + "/" + s.name
+				if color {
+					name += "/color"
+				}
+
+				b.Run(name, func(b *testing.B) {
+					jp := NewPrinter()
+					jp.SetStyle(s.style)
+					jp.SetTermWidth(80)
+					jp.SetColor(color)
+
+					b.ReportAllocs()
+					for i := 0; i < b.N; i++ {
+						rewind(jp)
+						putValue(jp, d.doc)
+					}
+					b.SetBytes(int64(jp.buffer.Len()))
+				})
+			}
+		}
+
+		b.Run(d.name+"/json.Marshal", func(b *testing.B) {
+			var n int
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				out, _ := json.Marshal(d.doc)
+				n = len(out)
+			}
+			b.SetBytes(int64(n))
+		})
+
+		b.Run(d.name+"/json.MarshalIndent", func(b *testing.B) {
+			var n int
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				out, _ := json.MarshalIndent(d.doc, "", "  ")
+				n = len(out)
+			}
+			b.SetBytes(int64(n))
+		})
+
+		b.Run(d.name+"/json.Encoder", func(b *testing.B) {
+			var buffer bytes.Buffer
+			enc := json.NewEncoder(&buffer)
+
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				buffer.Reset()
+				enc.Encode(d.doc)
+			}
+			b.SetBytes(int64(buffer.Len()))
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package projson
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// In canonical mode, values are put as RFC 8785 (JCS) canonical JSON:
+// without whitespace, with object members sorted by the UTF-16 code units
+// of their keys, numbers serialized as in ECMAScript, and strings escaped
+// minimally. Members of each open object are buffered until it is
+// finished, so keys may be put in any order.
+
+type canonicalMember struct {
+	key   []uint16
+	text  string
+	value *bytes.Buffer
+}
+
+type canonicalFrame struct {
+	object  bool
+	count   int               // number of elements of the array
+	buffer  *bytes.Buffer     // the array being written
+	members []canonicalMember // members of the object
+}
+
+// canonicalEmitter writes canonical JSON.
+type canonicalEmitter struct {
+	printer *JsonPrinter
+	stack   []*canonicalFrame
+}
+
+// out returns the buffer the next value is written to, after writing a
+// separator if needed.
+func (e *canonicalEmitter) out() *bytes.Buffer {
+	if len(e.stack) == 0 {
+		return e.printer.buffer
+	}
+
+	frame := e.stack[len(e.stack)-1]
+	if frame.object {
+		return frame.members[len(frame.members)-1].value
+	}
+	if frame.count > 0 {
+		frame.buffer.WriteByte(',')
+	}
+	frame.count++
+	return frame.buffer
+}
+
+func (e *canonicalEmitter) BeginArray(count int) error {
+	buffer := e.out()
+	buffer.WriteByte('[')
+	e.stack = append(e.stack, &canonicalFrame{buffer: buffer})
+	return nil
+}
+
+func (e *canonicalEmitter) EndArray() error {
+	frame := e.stack[len(e.stack)-1]
+	e.stack = e.stack[:len(e.stack)-1]
+	frame.buffer.WriteByte(']')
+	return nil
+}
+
+func (e *canonicalEmitter) BeginObject(count int) error {
+	e.stack = append(e.stack, &canonicalFrame{object: true, buffer: e.out()})
+	return nil
+}
+
+func (e *canonicalEmitter) EndObject() error {
+	frame := e.stack[len(e.stack)-1]
+	e.stack = e.stack[:len(e.stack)-1]
+
+	members := frame.members
+	sort.SliceStable(members, func(i, j int) bool {
+		return compareUTF16(members[i].key, members[j].key) < 0
+	})
+
+	frame.buffer.WriteByte('{')
+	for i, member := range members {
+		if i > 0 {
+			if compareUTF16(members[i-1].key, member.key) == 0 {
+				return errors.New("Duplicate key " + member.text + " in canonical JSON")
+			}
+			frame.buffer.WriteByte(',')
+		}
+		frame.buffer.WriteString(member.text)
+		frame.buffer.WriteByte(':')
+		frame.buffer.Write(member.value.Bytes())
+	}
+	frame.buffer.WriteByte('}')
+	return nil
+}
+
+func (e *canonicalEmitter) Key(key string) error {
+	frame := e.stack[len(e.stack)-1]
+	key = strings.ToValidUTF8(key, "\uFFFD")
+	frame.members = append(frame.members, canonicalMember{
+		key:   utf16.Encode([]rune(key)),
+		text:  canonicalString(key),
+		value: bytes.NewBuffer([]byte{}),
+	})
+	return nil
+}
+
+func (e *canonicalEmitter) Scalar(s Scalar) error {
+	var text string
+	switch v := s.Value.(type) {
+	case int64:
+		text = canonicalNumber(float64(v))
+	case uint64:
+		text = canonicalNumber(float64(v))
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return errors.New("NaN and Infinity cannot be represented in canonical JSON")
+		}
+		text = canonicalNumber(v)
+	case string:
+		text = canonicalString(v)
+	default:
+		text = s.Literal
+	}
+
+	e.out().WriteString(text)
+	return nil
+}
+
+func compareUTF16(a []uint16, b []uint16) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return int(a[i]) - int(b[i])
+		}
+	}
+	return len(a) - len(b)
+}
+
+// canonicalNumber serializes v as ECMAScript's Number.prototype.toString.
+func canonicalNumber(v float64) string {
+	if v == 0 {
+		return "0"
+	}
+
+	sign := ""
+	if v < 0 {
+		sign, v = "-", -v
+	}
+
+	// shortest digits d1 d2 ... dk, with v = 0.d1d2...dk * 10^n
+	e := strconv.FormatFloat(v, 'e', -1, 64)
+	mant, exp := e[:strings.IndexByte(e, 'e')], e[strings.IndexByte(e, 'e')+1:]
+	digits := strings.Replace(mant, ".", "", 1)
+	n, _ := strconv.Atoi(exp)
+	n++
+	k := len(digits)
+
+	switch {
+	case k <= n && n <= 21:
+		return sign + digits + strings.Repeat("0", n-k)
+	case 0 < n && n <= 21:
+		return sign + digits[:n] + "." + digits[n:]
+	case -6 < n && n <= 0:
+		return sign + "0." + strings.Repeat("0", -n) + digits
+	}
+
+	expsign := "+"
+	if n-1 < 0 {
+		expsign = "-"
+	}
+	exptext := expsign + strconv.Itoa(abs(n-1))
+	if k == 1 {
+		return sign + digits + "e" + exptext
+	}
+	return sign + digits[:1] + "." + digits[1:] + "e" + exptext
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// canonicalString quotes s, escaping only what JSON requires.
+func canonicalString(s string) string {
+	const hex = "0123456789abcdef"
+
+	var buffer bytes.Buffer
+	buffer.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			buffer.WriteByte('\\')
+			buffer.WriteRune(r)
+		case r == '\b':
+			buffer.WriteString(`\b`)
+		case r == '\t':
+			buffer.WriteString(`\t`)
+		case r == '\n':
+			buffer.WriteString(`\n`)
+		case r == '\f':
+			buffer.WriteString(`\f`)
+		case r == '\r':
+			buffer.WriteString(`\r`)
+		case r < 0x20:
+			buffer.WriteString(`\u00`)
+			buffer.WriteByte(hex[r>>4])
+			buffer.WriteByte(hex[r&0xf])
+		default:
+			buffer.WriteRune(r)
+		}
+	}
+	buffer.WriteByte('"')
+	return buffer.String()
+}
@@ -0,0 +1,100 @@
+package projson
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCanonical(t *testing.T) {
+	// RFC 8785, sections 3.2.2 and 3.2.3
+	cases := []struct {
+		src      string
+		expected string
+	}{
+		{`{
+  "numbers": [333333333.33333329, 1E30, 4.50, 2e-3, 0.000000000000000000000000001],
+  "string": "\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/",
+  "literals": [null, true, false]
+}`, `{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],"string":"€$\u000f\nA'B\"\\\\\"/"}`},
+		{`{
+  "\u20ac": "Euro Sign",
+  "\r": "Carriage Return",
+  "\ufb33": "Hebrew Letter Dalet With Dagesh",
+  "1": "One",
+  "\ud83d\ude00": "Emoji: Grinning Face",
+  "\u0080": "Control",
+  "\u00f6": "Latin Small Letter O With Diaeresis"
+}`, "{\"\\r\":\"Carriage Return\",\"1\":\"One\",\"\u0080\":\"Control\",\"ö\":\"Latin Small Letter O With Diaeresis\"," +
+			"\"€\":\"Euro Sign\",\"\U0001F600\":\"Emoji: Grinning Face\",\"\ufb33\":\"Hebrew Letter Dalet With Dagesh\"}"},
+		{`{"b": [{"d": 1, "c": {"f": 2, "e": 3}}, 10, "<&>"], "a": {}}`, `{"a":{},"b":[{"c":{"e":3,"f":2},"d":1},10,"<&>"]}`},
+	}
+
+	for _, c := range cases {
+		jp := NewPrinter()
+		jp.SetStyle(PrettyStyle)
+		jp.SetColor(true)
+		jp.SetCanonical(true)
+		if err := putJSON(jp, c.src); err != nil {
+			t.Fatal(err)
+		}
+
+		if actual, err := jp.String(); err != nil || c.expected != actual {
+			t.Errorf("expected: %v\nactual: %v (%v)", c.expected, actual, err)
+		}
+	}
+}
+
+func TestCanonicalNumber(t *testing.T) {
+	// RFC 8785, Appendix B
+	cases := []struct {
+		bits     uint64
+		expected string
+	}{
+		{0x0000000000000000, "0"},
+		{0x8000000000000000, "0"},
+		{0x0000000000000001, "5e-324"},
+		{0x8000000000000001, "-5e-324"},
+		{0x7fefffffffffffff, "1.7976931348623157e+308"},
+		{0xffefffffffffffff, "-1.7976931348623157e+308"},
+		{0x4340000000000000, "9007199254740992"},
+		{0xc340000000000000, "-9007199254740992"},
+		{0x4430000000000000, "295147905179352830000"},
+		{0x44b52d02c7e14af5, "9.999999999999997e+22"},
+		{0x44b52d02c7e14af6, "1e+23"},
+		{0x44b52d02c7e14af7, "1.0000000000000001e+23"},
+		{0x444b1ae4d6e2ef4e, "999999999999999700000"},
+		{0x444b1ae4d6e2ef4f, "999999999999999900000"},
+		{0x444b1ae4d6e2ef50, "1e+21"},
+		{0x3eb0c6f7a0b5ed8c, "9.999999999999997e-7"},
+		{0x3eb0c6f7a0b5ed8d, "0.000001"},
+		{0x41b3de4355555553, "333333333.3333332"},
+		{0x41b3de4355555554, "333333333.33333325"},
+		{0x41b3de4355555555, "333333333.3333333"},
+		{0x41b3de4355555556, "333333333.3333334"},
+		{0x41b3de4355555557, "333333333.33333343"},
+		{0xbecbf647612f3696, "-0.0000033333333333333333"},
+		{0x43143ff3c1cb0959, "1424953923781206.2"},
+	}
+
+	for _, c := range cases {
+		if actual := canonicalNumber(math.Float64frombits(c.bits)); c.expected != actual {
+			t.Errorf("%016x\nexpected: %v\nactual: %v", c.bits, c.expected, actual)
+		}
+	}
+}
+
+func TestCanonicalError(t *testing.T) {
+	for _, v := range []float64{math.NaN(), math.Inf(1), math.Inf(-1)} {
+		jp := NewPrinter()
+		jp.SetCanonical(true)
+		if err := jp.PutFloat(v); err == nil {
+			t.Errorf("%v must be an error", v)
+		}
+	}
+
+	jp := NewPrinter()
+	jp.SetCanonical(true)
+	if err := jp.Obj().Key("a").Int(1).Key("a").Int(2).End().Error(); err == nil {
+		t.Errorf("duplicate keys must be an error")
+	}
+}
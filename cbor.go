@@ -0,0 +1,200 @@
+package projson
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"reflect"
+)
+
+// CBORFormat puts values as CBOR (RFC 8949) data items, one after another
+// as a CBOR sequence. Items are written as soon as they are put, so arrays
+// and objects opened with BeginArray and BeginObject have indefinite
+// lengths; PutArray and PutObject know their lengths in advance. Floats
+// take the shortest encoding that keeps their value, and formats given to
+// PutFloatFmt are not applied. PutBytes writes byte strings.
+
+// cborEmitter writes data items as events come.
+type cborEmitter struct {
+	printer *JsonPrinter
+	counts  []int // count of each open container
+}
+
+func (e *cborEmitter) begin(major byte, count int) error {
+	e.printer.writeCBORHead(major, count)
+	e.counts = append(e.counts, count)
+	return nil
+}
+
+func (e *cborEmitter) end() error {
+	if e.counts[len(e.counts)-1] < 0 {
+		e.printer.buffer.WriteByte(cborBreak)
+	}
+	e.counts = e.counts[:len(e.counts)-1]
+	return nil
+}
+
+func (e *cborEmitter) BeginArray(count int) error {
+	return e.begin(cborArray, count)
+}
+
+func (e *cborEmitter) EndArray() error {
+	return e.end()
+}
+
+func (e *cborEmitter) BeginObject(count int) error {
+	return e.begin(cborMap, count)
+}
+
+func (e *cborEmitter) EndObject() error {
+	return e.end()
+}
+
+func (e *cborEmitter) Key(key string) error {
+	return e.printer.writeCBORValue(key)
+}
+
+func (e *cborEmitter) Scalar(s Scalar) error {
+	if s.bytes != nil {
+		e.printer.writeCBORHead(cborBytes, len(s.bytes))
+		e.printer.buffer.Write(s.bytes)
+		return nil
+	}
+	return e.printer.writeCBORValue(s.Value)
+}
+
+const (
+	cborUint   = 0
+	cborNegint = 1
+	cborBytes  = 2
+	cborText   = 3
+	cborArray  = 4
+	cborMap    = 5
+	cborSimple = 7
+
+	cborFalse = 20
+	cborTrue  = 21
+	cborNull  = 22
+
+	cborIndefinite = 31
+	cborBreak      = 0xff
+)
+
+// writeCBORHead writes the head of a data item with argument n, or of an
+// indefinite-length item if n is negative.
+func (printer *JsonPrinter) writeCBORHead(major byte, n int) {
+	if n < 0 {
+		printer.buffer.WriteByte(major<<5 | cborIndefinite)
+		return
+	}
+	printer.writeCBORUint(major, uint64(n))
+}
+
+func (printer *JsonPrinter) writeCBORUint(major byte, n uint64) {
+	var buf [9]byte
+	switch {
+	case n < 24:
+		printer.buffer.WriteByte(major<<5 | byte(n))
+		return
+	case n <= math.MaxUint8:
+		buf[0], buf[1] = major<<5|24, byte(n)
+		printer.buffer.Write(buf[:2])
+	case n <= math.MaxUint16:
+		buf[0] = major<<5 | 25
+		binary.BigEndian.PutUint16(buf[1:], uint16(n))
+		printer.buffer.Write(buf[:3])
+	case n <= math.MaxUint32:
+		buf[0] = major<<5 | 26
+		binary.BigEndian.PutUint32(buf[1:], uint32(n))
+		printer.buffer.Write(buf[:5])
+	default:
+		buf[0] = major<<5 | 27
+		binary.BigEndian.PutUint64(buf[1:], n)
+		printer.buffer.Write(buf[:9])
+	}
+}
+
+func (printer *JsonPrinter) writeCBORValue(value interface{}) error {
+	switch v := value.(type) {
+	case int64:
+		if v >= 0 {
+			printer.writeCBORUint(cborUint, uint64(v))
+		} else {
+			printer.writeCBORUint(cborNegint, uint64(-1-v))
+		}
+	case uint64:
+		printer.writeCBORUint(cborUint, v)
+	case float64:
+		printer.writeCBORFloat(v)
+	case string:
+		printer.writeCBORHead(cborText, len(v))
+		printer.buffer.WriteString(v)
+	case bool:
+		if v {
+			printer.buffer.WriteByte(cborSimple<<5 | cborTrue)
+		} else {
+			printer.buffer.WriteByte(cborSimple<<5 | cborFalse)
+		}
+	case nil:
+		printer.buffer.WriteByte(cborSimple<<5 | cborNull)
+	default:
+		return errors.New("Cannot put value of type " + reflect.TypeOf(value).String() + " in CBOR")
+	}
+	return nil
+}
+
+// writeCBORFloat writes v as a half, single or double precision float,
+// whichever is the shortest to keep its value.
+func (printer *JsonPrinter) writeCBORFloat(v float64) {
+	var buf [9]byte
+	if h, ok := toHalf(v); ok {
+		buf[0] = cborSimple<<5 | 25
+		binary.BigEndian.PutUint16(buf[1:], h)
+		printer.buffer.Write(buf[:3])
+	} else if float64(float32(v)) == v {
+		buf[0] = cborSimple<<5 | 26
+		binary.BigEndian.PutUint32(buf[1:], math.Float32bits(float32(v)))
+		printer.buffer.Write(buf[:5])
+	} else {
+		buf[0] = cborSimple<<5 | 27
+		binary.BigEndian.PutUint64(buf[1:], math.Float64bits(v))
+		printer.buffer.Write(buf[:9])
+	}
+}
+
+// toHalf returns v as an IEEE 754 half precision float, if it is exactly
+// representable. NaNs become the canonical quiet NaN.
+func toHalf(v float64) (uint16, bool) {
+	if math.IsNaN(v) {
+		return 0x7e00, true
+	}
+	if float64(float32(v)) != v {
+		return 0, false
+	}
+
+	bits := math.Float32bits(float32(v))
+	sign := uint16(bits>>16) & 0x8000
+	exp := int(bits>>23&0xff) - 127
+	mant := bits & 0x7fffff
+
+	switch {
+	case v == 0:
+		return sign, true
+	case math.IsInf(v, 0):
+		return sign | 0x7c00, true
+	case exp >= -14 && exp <= 15:
+		if mant&0x1fff != 0 {
+			return 0, false
+		}
+		return sign | uint16(exp+15)<<10 | uint16(mant>>13), true
+	case exp >= -24 && exp < -14:
+		// subnormal
+		shift := uint(13 - 14 - exp)
+		mant |= 0x800000
+		if mant&(1<<shift-1) != 0 {
+			return 0, false
+		}
+		return sign | uint16(mant>>shift), true
+	}
+	return 0, false
+}
@@ -0,0 +1,263 @@
+package projson
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math"
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+// decodeCBOR decodes the first data item in data into compact JSON,
+// independently of the encoder, and returns the rest of data.
+func decodeCBOR(data []byte, out *bytes.Buffer) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, errors.New("unexpected end of data")
+	}
+	major, info := data[0]>>5, data[0]&0x1f
+	data = data[1:]
+
+	var arg uint64
+	switch {
+	case info < 24:
+		arg = uint64(info)
+	case info <= 27:
+		size := 1 << (info - 24)
+		if len(data) < size {
+			return nil, errors.New("truncated argument")
+		}
+		for _, b := range data[:size] {
+			arg = arg<<8 | uint64(b)
+		}
+		data = data[size:]
+	case info == 31 && (major == 4 || major == 5):
+		// indefinite length
+		out.WriteByte("[{"[major-4])
+		for i := 0; ; i++ {
+			if len(data) > 0 && data[0] == 0xff {
+				out.WriteByte("]}"[major-4])
+				return data[1:], nil
+			}
+			if i > 0 {
+				out.WriteByte(',')
+			}
+			var err error
+			if data, err = decodeCBORMember(data, major == 5, out); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, errors.New("unsupported additional information")
+	}
+
+	switch major {
+	case 0:
+		out.WriteString(strconv.FormatUint(arg, 10))
+	case 1:
+		out.WriteString("-" + strconv.FormatUint(arg+1, 10))
+	case 3:
+		if uint64(len(data)) < arg {
+			return nil, errors.New("truncated string")
+		}
+		s, _ := json.Marshal(string(data[:arg]))
+		out.Write(s)
+		data = data[arg:]
+	case 4, 5:
+		out.WriteByte("[{"[major-4])
+		for i := uint64(0); i < arg; i++ {
+			if i > 0 {
+				out.WriteByte(',')
+			}
+			var err error
+			if data, err = decodeCBORMember(data, major == 5, out); err != nil {
+				return nil, err
+			}
+		}
+		out.WriteByte("]}"[major-4])
+	case 7:
+		var f float64
+		switch info {
+		case 25:
+			f = halfToFloat(uint16(arg))
+		case 26:
+			f = float64(math.Float32frombits(uint32(arg)))
+		case 27:
+			f = math.Float64frombits(arg)
+		case 20, 21, 22:
+			out.WriteString([]string{"false", "true", "null"}[info-20])
+			return data, nil
+		default:
+			return nil, errors.New("unsupported simple value")
+		}
+		out.WriteString(strconv.FormatFloat(f, 'f', -1, 64))
+	default:
+		return nil, errors.New("unsupported major type")
+	}
+	return data, nil
+}
+
+func decodeCBORMember(data []byte, keyed bool, out *bytes.Buffer) ([]byte, error) {
+	var err error
+	if keyed {
+		if data, err = decodeCBOR(data, out); err != nil {
+			return nil, err
+		}
+		out.WriteByte(':')
+	}
+	return decodeCBOR(data, out)
+}
+
+func halfToFloat(h uint16) float64 {
+	exp, mant := int(h>>10&0x1f), float64(h&0x3ff)
+	var f float64
+	switch exp {
+	case 0:
+		f = math.Ldexp(mant, -24)
+	case 31:
+		if mant == 0 {
+			f = math.Inf(1)
+		} else {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(mant+1024, exp-25)
+	}
+	if h&0x8000 != 0 {
+		f = -f
+	}
+	return f
+}
+
+// checkBinaryRoundTrip puts random documents in format, decodes them and
+// compares them with SimpleStyle output.
+func checkBinaryRoundTrip(t *testing.T, format int, decode func([]byte, *bytes.Buffer) ([]byte, error)) {
+	g := &randomDoc{
+		words:   []string{"a", "bc", "日本", "\U0001F600", "long-long-long-long-long-long-long-word"},
+		long:    map[string]bool{},
+		maxAtom: 1000,
+	}
+
+	for seed := int64(0); seed < 500; seed++ {
+		g.rnd = rand.New(rand.NewSource(seed))
+		jp := NewPrinter()
+		jp.SetFormat(format)
+		g.put(jp, "", 5)
+		actual, err := jp.String()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		g.rnd = rand.New(rand.NewSource(seed))
+		jp = NewPrinter()
+		g.put(jp, "", 5)
+		expected, _ := jp.String()
+
+		var decoded bytes.Buffer
+		rest, err := decode([]byte(actual), &decoded)
+		if err != nil || len(rest) != 0 {
+			t.Fatalf("seed %d: %v (%d bytes left)\n%x", seed, err, len(rest), actual)
+		}
+		if decoded.String() != expected {
+			t.Fatalf("seed %d\nexpected: %v\nactual: %v", seed, expected, decoded.String())
+		}
+	}
+}
+
+func TestCBORRoundTrip(t *testing.T) {
+	checkBinaryRoundTrip(t, CBORFormat, decodeCBOR)
+}
+
+func TestCBORVectors(t *testing.T) {
+	// from RFC 8949, Appendix A
+	cases := []struct {
+		put      func(jp *JsonPrinter)
+		expected string
+	}{
+		{func(jp *JsonPrinter) { jp.PutInt(0) }, "00"},
+		{func(jp *JsonPrinter) { jp.PutInt(23) }, "17"},
+		{func(jp *JsonPrinter) { jp.PutInt(24) }, "1818"},
+		{func(jp *JsonPrinter) { jp.PutInt(1000) }, "1903e8"},
+		{func(jp *JsonPrinter) { jp.PutInt64(1000000000000) }, "1b000000e8d4a51000"},
+		{func(jp *JsonPrinter) { jp.PutInt(-1) }, "20"},
+		{func(jp *JsonPrinter) { jp.PutInt(-1000) }, "3903e7"},
+		{func(jp *JsonPrinter) { jp.PutFloat(0) }, "f90000"},
+		{func(jp *JsonPrinter) { jp.PutFloat(math.Copysign(0, -1)) }, "f98000"},
+		{func(jp *JsonPrinter) { jp.PutFloat(1.5) }, "f93e00"},
+		{func(jp *JsonPrinter) { jp.PutFloat(65504) }, "f97bff"},
+		{func(jp *JsonPrinter) { jp.PutFloat(100000) }, "fa47c35000"},
+		{func(jp *JsonPrinter) { jp.PutFloat(3.4028234663852886e+38) }, "fa7f7fffff"},
+		{func(jp *JsonPrinter) { jp.PutFloat(1.1) }, "fb3ff199999999999a"},
+		{func(jp *JsonPrinter) { jp.PutFloat(5.960464477539063e-8) }, "f90001"},
+		{func(jp *JsonPrinter) { jp.PutFloat(0.00006103515625) }, "f90400"},
+		{func(jp *JsonPrinter) { jp.PutFloat(-4) }, "f9c400"},
+		{func(jp *JsonPrinter) { jp.PutFloat(math.Inf(1)) }, "f97c00"},
+		{func(jp *JsonPrinter) { jp.PutFloat(math.NaN()) }, "f97e00"},
+		{func(jp *JsonPrinter) { jp.PutFloat(math.Inf(-1)) }, "f9fc00"},
+		{func(jp *JsonPrinter) { jp.PutBool(false) }, "f4"},
+		{func(jp *JsonPrinter) { jp.PutBool(true) }, "f5"},
+		{func(jp *JsonPrinter) { jp.PutNull() }, "f6"},
+		{func(jp *JsonPrinter) { jp.PutString("") }, "60"},
+		{func(jp *JsonPrinter) { jp.PutString("ü") }, "62c3bc"},
+		{func(jp *JsonPrinter) { jp.PutString("\U00010151") }, "64f0908591"},
+		{func(jp *JsonPrinter) { jp.PutArray([]interface{}{}) }, "80"},
+		{func(jp *JsonPrinter) { jp.PutArray([]interface{}{1, 2, 3}) }, "83010203"},
+		{func(jp *JsonPrinter) { jp.PutObject(map[string]interface{}{"a": 1}) }, "a1616101"},
+		{func(jp *JsonPrinter) { jp.Arr().End() }, "9fff"},
+		{func(jp *JsonPrinter) { jp.Arr().Int(1).Array([]interface{}{2, 3}).Arr().Int(4).Int(5).End().End() }, "9f018202039f0405ffff"},
+		{func(jp *JsonPrinter) { jp.Obj().Key("a").Int(1).Key("b").Arr().Int(2).Int(3).End().End() }, "bf61610161629f0203ffff"},
+		{func(jp *JsonPrinter) { jp.PutBytes([]byte{}) }, "40"},
+		{func(jp *JsonPrinter) { jp.PutBytes([]byte{1, 2, 3, 4}) }, "4401020304"},
+		{func(jp *JsonPrinter) { jp.PutValue(struct{ B []byte }{[]byte{1, 2}}) }, "bf6142420102ff"},
+		{func(jp *JsonPrinter) {
+			recorder := NewRecorder()
+			src := NewPrinter()
+			src.SetEmitter(recorder)
+			src.PutBytes([]byte{1, 2})
+			recorder.Replay(jp)
+		}, "420102"},
+		// a CBOR sequence (RFC 8742)
+		{func(jp *JsonPrinter) { jp.Int(1).Arr().End().Str("a") }, "019fff6161"},
+	}
+
+	for _, c := range cases {
+		jp := NewPrinter()
+		jp.SetFormat(CBORFormat)
+		c.put(jp)
+		actual, err := jp.String()
+		if err != nil || hex.EncodeToString([]byte(actual)) != c.expected {
+			t.Errorf("expected: %v\nactual: %x (%v)", c.expected, actual, err)
+		}
+	}
+}
+
+func TestCBORUnexpectedValue(t *testing.T) {
+	jp := NewPrinter()
+	jp.SetFormat(CBORFormat)
+	if err := jp.AsEmitter().Scalar(Scalar{Kind: IntScalar, Literal: "1", Value: 1}); err == nil {
+		t.Errorf("expected: error\nactual: nil")
+	}
+}
+
+func TestHalfFloat(t *testing.T) {
+	for bits := 0; bits < 0x10000; bits++ {
+		f := halfToFloat(uint16(bits))
+		h, ok := toHalf(f)
+		switch {
+		case math.IsNaN(f):
+			if !ok || h != 0x7e00 {
+				t.Errorf("%04x: expected: 7e00\nactual: %04x", bits, h)
+			}
+		case !ok || h != uint16(bits):
+			t.Errorf("expected: %04x\nactual: %04x (%v)", bits, h, ok)
+		}
+	}
+
+	for _, f := range []float64{1.1, 65520, 1e-8, 3.0000001} {
+		if _, ok := toHalf(f); ok {
+			t.Errorf("%v must not be a half float", f)
+		}
+	}
+}
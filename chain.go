@@ -0,0 +1,94 @@
+package projson
+
+import (
+	"errors"
+)
+
+// Chainable counterparts of the Put*/Begin*/Finish* API. They do not
+// return errors; the first error is kept in the printer and reported by
+// Error() and String(), and every later call is a no-op. Errors of the
+// Set* methods, e.g. changing the style after putting some items, are
+// kept likewise.
+//
+//	str, err := projson.NewPrinter().Obj().Key("a").Int(1).End().String()
+
+func (printer *JsonPrinter) Arr() *JsonPrinter {
+	printer.BeginArray()
+	return printer
+}
+
+func (printer *JsonPrinter) Obj() *JsonPrinter {
+	printer.BeginObject()
+	return printer
+}
+
+// End finishes the innermost array or object.
+func (printer *JsonPrinter) End() *JsonPrinter {
+	if printer.err != nil {
+		return printer
+	}
+
+	if len(printer.pathStack) == 0 {
+		printer.err = errors.New("No array/object to end")
+		return printer
+	}
+
+	switch printer.pathStack[len(printer.pathStack)-1].typ {
+	case frameArray:
+		printer.FinishArray()
+	case frameObject:
+		printer.FinishObject()
+	}
+
+	return printer
+}
+
+func (printer *JsonPrinter) Key(v string) *JsonPrinter {
+	printer.PutKey(v)
+	return printer
+}
+
+func (printer *JsonPrinter) Int(v int) *JsonPrinter {
+	printer.PutInt(v)
+	return printer
+}
+
+func (printer *JsonPrinter) Int64(v int64) *JsonPrinter {
+	printer.PutInt64(v)
+	return printer
+}
+
+func (printer *JsonPrinter) Bool(v bool) *JsonPrinter {
+	printer.PutBool(v)
+	return printer
+}
+
+func (printer *JsonPrinter) Null() *JsonPrinter {
+	printer.PutNull()
+	return printer
+}
+
+func (printer *JsonPrinter) Float(v float64) *JsonPrinter {
+	printer.PutFloat(v)
+	return printer
+}
+
+func (printer *JsonPrinter) FloatFmt(v float64, fmtstr string) *JsonPrinter {
+	printer.PutFloatFmt(v, fmtstr)
+	return printer
+}
+
+func (printer *JsonPrinter) Str(v string) *JsonPrinter {
+	printer.PutString(v)
+	return printer
+}
+
+func (printer *JsonPrinter) Array(arr []interface{}) *JsonPrinter {
+	printer.PutArray(arr)
+	return printer
+}
+
+func (printer *JsonPrinter) Object(m map[string]interface{}) *JsonPrinter {
+	printer.PutObject(m)
+	return printer
+}
@@ -0,0 +1,77 @@
+package projson
+
+import "testing"
+
+func TestChain(t *testing.T) {
+	jp := NewPrinter()
+
+	actual, err := jp.Obj().
+		Key("a").Int(1).
+		Key("b").Arr().Int64(2).Float(3.5).FloatFmt(1.2345, "%.2f").Str("four").End().
+		Key("c").Obj().Key("d").Array([]interface{}{1, "x"}).End().
+		Key("e").Object(map[string]interface{}{"f": 6}).
+		End().String()
+
+	expectNil(t, err)
+	expected := `{"a":1,"b":[2,3.5,1.23,"four"],"c":{"d":[1,"x"]},"e":{"f":6}}`
+	if actual != expected {
+		t.Errorf("expected: %v\nactual: %v", expected, actual)
+	}
+}
+
+func TestChainStickyError(t *testing.T) {
+	jp := NewPrinter()
+
+	// Int without a preceding Key is an error; everything after it is ignored
+	actual, err := jp.Obj().Int(1).Key("a").Int(2).End().String()
+	if err == nil {
+		t.Error("expected: err != nil\nactual: err == nil")
+	}
+	if actual != "" {
+		t.Errorf("empty string should be returned on error")
+	}
+	if jp.Error() != err {
+		t.Errorf("expected: %v\nactual: %v", err, jp.Error())
+	}
+
+	jp.Reset()
+	if _, err := jp.End().String(); err == nil {
+		t.Error("End without any array/object should fail")
+	}
+}
+
+func TestStickyError(t *testing.T) {
+	jp := NewPrinter()
+
+	jp.BeginArray()
+	first := jp.PutKey("key")
+	expectNonNil(t, first)
+
+	// every method reports the first error, not a new one
+	if err := jp.PutInt(1); err != first {
+		t.Errorf("PutInt: expected: %v\nactual: %v", first, err)
+	}
+	if err := jp.PutString("s"); err != first {
+		t.Errorf("PutString: expected: %v\nactual: %v", first, err)
+	}
+	if err := jp.PutKey("k"); err != first {
+		t.Errorf("PutKey: expected: %v\nactual: %v", first, err)
+	}
+	if err := jp.FinishArray(); err != first {
+		t.Errorf("FinishArray: expected: %v\nactual: %v", first, err)
+	}
+	if err := jp.SetStyle(SmartStyle); err != first {
+		t.Errorf("SetStyle: expected: %v\nactual: %v", first, err)
+	}
+	if _, err := jp.String(); err != first {
+		t.Errorf("String: expected: %v\nactual: %v", first, err)
+	}
+
+	jp.Reset()
+	if err := jp.PutArray([]interface{}{complex64(1)}); err == nil {
+		t.Error("expected: err != nil\nactual: err == nil")
+	}
+	if jp.Error() == nil {
+		t.Error("unknown type in PutArray should be sticky")
+	}
+}
@@ -0,0 +1,406 @@
+// Command projson-gen reads a JSON Schema and generates Go types with
+// functions putting them to a projson printer, so that the documents
+// printed have the structure of the schema:
+//
+//	projson-gen -schema user.json -type User -package model -o user_projson.go
+//
+// generates a type User and
+//
+//	func WriteUser(p *projson.JsonPrinter, v *User) error
+//
+// The schema must be an object or an array, and each value in it must
+// have a single type (possibly with "null"). Enums are not supported, as
+// the wrappers could not keep other values out.
+// Properties not required get pointer types and are omitted when nil;
+// nullable values get pointer types and are put as null when nil.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"os"
+	"strings"
+	"unicode"
+)
+
+var (
+	schemaPath = flag.String("schema", "", "JSON Schema file")
+	typeName   = flag.String("type", "", "Go type name of the documents")
+	pkgName    = flag.String("package", "main", "package of the generated code")
+	importPath = flag.String("import", "github.com/hayamiz/go-projson", "import path of projson")
+	output     = flag.String("o", "", "output file (default: standard output)")
+)
+
+func main() {
+	flag.Parse()
+	if *schemaPath == "" || *typeName == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	src, err := ioutil.ReadFile(*schemaPath)
+	if err == nil {
+		src, err = generate(src, *typeName, *pkgName, *importPath)
+	}
+	if err == nil {
+		if *output == "" {
+			_, err = os.Stdout.Write(src)
+		} else {
+			err = ioutil.WriteFile(*output, src, 0644)
+		}
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "projson-gen:", err)
+		os.Exit(1)
+	}
+}
+
+// object is a JSON object with the order of its members.
+type object struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+// decode decodes the next JSON value of dec, with objects as *object.
+func decode(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok {
+	case json.Delim('['):
+		arr := []interface{}{}
+		for dec.More() {
+			v, err := decode(dec)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+		_, err := dec.Token()
+		return arr, err
+	case json.Delim('{'):
+		obj := &object{values: map[string]interface{}{}}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			v, err := decode(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj.keys = append(obj.keys, key.(string))
+			obj.values[key.(string)] = v
+		}
+		_, err := dec.Token()
+		return obj, err
+	}
+	return tok, nil
+}
+
+type kind int
+
+const (
+	kindString kind = iota
+	kindInt
+	kindFloat
+	kindBool
+	kindStruct
+	kindSlice
+)
+
+// goType is the Go type of the values of a schema.
+type goType struct {
+	kind     kind
+	name     string // of a struct
+	elem     *goType
+	fields   []field
+	nullable bool
+}
+
+type field struct {
+	key      string
+	name     string
+	typ      *goType
+	required bool
+}
+
+type generator struct {
+	structs []*goType
+	names   map[string]bool
+}
+
+func generate(src []byte, typeName string, pkgName string, importPath string) ([]byte, error) {
+	schema, err := decode(json.NewDecoder(bytes.NewReader(src)))
+	if err != nil {
+		return nil, err
+	}
+
+	g := &generator{names: map[string]bool{}}
+	root, err := g.resolve(schema, typeName, "#")
+	if err != nil {
+		return nil, err
+	}
+	if root.kind != kindStruct && root.kind != kindSlice {
+		return nil, errors.New("the schema is not of an object or an array")
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by projson-gen. DO NOT EDIT.\n\npackage %s\n\n", pkgName)
+	fmt.Fprintf(&buf, "import (\n\tprojson %q\n)\n", importPath)
+
+	if root.kind == kindSlice {
+		fmt.Fprintf(&buf, "\ntype %s %s\n", typeName, g.typeExpr(root, false))
+		fmt.Fprintf(&buf, "\n// Write%s puts v to p.\n", typeName)
+		fmt.Fprintf(&buf, "func Write%s(p *projson.JsonPrinter, v %s) error {\n", typeName, typeName)
+		g.put(&buf, root, "v", 0)
+		fmt.Fprintf(&buf, "return p.Error()\n}\n")
+	}
+
+	for _, s := range g.structs {
+		fmt.Fprintf(&buf, "\ntype %s struct {\n", s.name)
+		for _, f := range s.fields {
+			tag := f.key
+			if !f.required {
+				tag += ",omitempty"
+			}
+			fmt.Fprintf(&buf, "%s %s `json:%q`\n", f.name, g.typeExpr(f.typ, !f.required), tag)
+		}
+		fmt.Fprintf(&buf, "}\n")
+
+		fmt.Fprintf(&buf, "\n// Write%s puts v to p.\n", s.name)
+		fmt.Fprintf(&buf, "func Write%s(p *projson.JsonPrinter, v *%s) error {\n", s.name, s.name)
+		g.putStruct(&buf, s, "v")
+		fmt.Fprintf(&buf, "return p.Error()\n}\n")
+	}
+
+	return format.Source(buf.Bytes())
+}
+
+// resolve returns the type of the schema s at the JSON pointer ptr. Struct
+// types are named name.
+func (g *generator) resolve(s interface{}, name string, ptr string) (*goType, error) {
+	unsupported := func(msg string) error {
+		return errors.New("unsupported schema at " + ptr + ": " + msg)
+	}
+
+	schema, ok := s.(*object)
+	if !ok {
+		return nil, unsupported("not an object")
+	}
+	if _, ok := schema.values["enum"]; ok {
+		return nil, unsupported("enum")
+	}
+
+	var types []string
+	switch t := schema.values["type"].(type) {
+	case string:
+		types = []string{t}
+	case []interface{}:
+		for _, t := range t {
+			if t, ok := t.(string); ok {
+				types = append(types, t)
+			}
+		}
+	}
+
+	t := &goType{}
+	var typ string
+	for _, name := range types {
+		if name == "null" {
+			t.nullable = true
+		} else if typ == "" {
+			typ = name
+		} else {
+			return nil, unsupported("more than one type")
+		}
+	}
+
+	switch typ {
+	case "string":
+		t.kind = kindString
+	case "integer":
+		t.kind = kindInt
+	case "number":
+		t.kind = kindFloat
+	case "boolean":
+		t.kind = kindBool
+	case "array":
+		t.kind = kindSlice
+		items, ok := schema.values["items"]
+		if !ok {
+			return nil, unsupported("array without items")
+		}
+		elem, err := g.resolve(items, name+"Item", ptr+"/items")
+		if err != nil {
+			return nil, err
+		}
+		t.elem = elem
+	case "object":
+		t.kind = kindStruct
+		t.name = name
+		if g.names[name] {
+			return nil, unsupported("type name " + name + " is used twice")
+		}
+		g.names[name] = true
+		g.structs = append(g.structs, t)
+
+		required := map[string]bool{}
+		if keys, ok := schema.values["required"].([]interface{}); ok {
+			for _, key := range keys {
+				if key, ok := key.(string); ok {
+					required[key] = true
+				}
+			}
+		}
+
+		fieldNames := map[string]bool{}
+		properties, _ := schema.values["properties"].(*object)
+		if properties == nil {
+			properties = &object{}
+		}
+		for _, key := range properties.keys {
+			fieldName := goName(key)
+			if fieldNames[fieldName] {
+				return nil, unsupported("properties named " + fieldName + " in Go")
+			}
+			fieldNames[fieldName] = true
+
+			ft, err := g.resolve(properties.values[key], name+fieldName, ptr+"/properties/"+key)
+			if err != nil {
+				return nil, err
+			}
+			t.fields = append(t.fields, field{key: key, name: fieldName, typ: ft, required: required[key]})
+		}
+	default:
+		return nil, unsupported("no type")
+	}
+
+	return t, nil
+}
+
+// goName returns an exported Go identifier for key.
+func goName(key string) string {
+	var name strings.Builder
+	upper := true
+	for _, r := range key {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if name.Len() == 0 && unicode.IsDigit(r) {
+				name.WriteString("X")
+			}
+			if upper {
+				r = unicode.ToUpper(r)
+			}
+			name.WriteRune(r)
+			upper = false
+		default:
+			upper = true
+		}
+	}
+	if name.Len() == 0 {
+		return "X"
+	}
+	return name.String()
+}
+
+// typeExpr returns the Go type of t, a pointer if it may be nil.
+func (g *generator) typeExpr(t *goType, optional bool) string {
+	var expr string
+	switch t.kind {
+	case kindString:
+		expr = "string"
+	case kindInt:
+		expr = "int64"
+	case kindFloat:
+		expr = "float64"
+	case kindBool:
+		expr = "bool"
+	case kindStruct:
+		expr = t.name
+	case kindSlice:
+		// nil slices stand for null and omitted values
+		return "[]" + g.typeExpr(t.elem, false)
+	}
+
+	if optional || t.nullable {
+		return "*" + expr
+	}
+	return expr
+}
+
+// putStruct writes code putting the struct pointed to by v.
+func (g *generator) putStruct(buf *bytes.Buffer, t *goType, v string) {
+	fmt.Fprintf(buf, "p.BeginObject()\n")
+	for _, f := range t.fields {
+		expr := v + "." + f.name
+		pointer := !f.required || f.typ.nullable
+		if f.typ.kind == kindSlice {
+			pointer = false
+		}
+
+		value := expr
+		if pointer {
+			value = "*" + expr
+		}
+
+		switch {
+		case !f.required && (pointer || f.typ.kind == kindSlice):
+			fmt.Fprintf(buf, "if %s != nil {\n", expr)
+			fmt.Fprintf(buf, "p.PutKey(%q)\n", f.key)
+			g.put(buf, f.typ, value, 0)
+			fmt.Fprintf(buf, "}\n")
+		case f.typ.nullable:
+			fmt.Fprintf(buf, "p.PutKey(%q)\n", f.key)
+			fmt.Fprintf(buf, "if %s == nil {\np.PutNull()\n} else {\n", expr)
+			g.put(buf, f.typ, value, 0)
+			fmt.Fprintf(buf, "}\n")
+		default:
+			fmt.Fprintf(buf, "p.PutKey(%q)\n", f.key)
+			g.put(buf, f.typ, value, 0)
+		}
+	}
+	fmt.Fprintf(buf, "p.FinishObject()\n")
+}
+
+// put writes code putting the value v of type t, in depth nested loops.
+func (g *generator) put(buf *bytes.Buffer, t *goType, v string, depth int) {
+	switch t.kind {
+	case kindString:
+		fmt.Fprintf(buf, "p.PutString(%s)\n", v)
+	case kindInt:
+		fmt.Fprintf(buf, "p.PutInt64(%s)\n", v)
+	case kindFloat:
+		fmt.Fprintf(buf, "p.PutFloat(%s)\n", v)
+	case kindBool:
+		fmt.Fprintf(buf, "p.PutBool(%s)\n", v)
+	case kindStruct:
+		if strings.HasPrefix(v, "*") {
+			fmt.Fprintf(buf, "Write%s(p, %s)\n", t.name, v[1:])
+		} else {
+			fmt.Fprintf(buf, "Write%s(p, &%s)\n", t.name, v)
+		}
+	case kindSlice:
+		i := fmt.Sprintf("i%d", depth)
+		elem := v + "[" + i + "]"
+		fmt.Fprintf(buf, "p.BeginArray()\n")
+		fmt.Fprintf(buf, "for %s := range %s {\n", i, v)
+		if t.elem.nullable && t.elem.kind != kindSlice {
+			fmt.Fprintf(buf, "if %s == nil {\np.PutNull()\n} else {\n", elem)
+			g.put(buf, t.elem, "*"+elem, depth+1)
+			fmt.Fprintf(buf, "}\n")
+		} else {
+			g.put(buf, t.elem, elem, depth+1)
+		}
+		fmt.Fprintf(buf, "}\n")
+		fmt.Fprintf(buf, "p.FinishArray()\n")
+	}
+}
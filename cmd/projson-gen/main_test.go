@@ -0,0 +1,130 @@
+package main
+
+import (
+	"flag"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files in testdata")
+
+// upperFirst returns s with its first letter in upper case, if ASCII.
+func upperFirst(s string) string {
+	if s != "" && s[0] >= 'a' && s[0] <= 'z' {
+		return string(s[0]-'a'+'A') + s[1:]
+	}
+	return s
+}
+
+// sourceImporter imports the package at path from the source in dir, and
+// the others as the default importer does.
+type sourceImporter struct {
+	fset *token.FileSet
+	path string
+	dir  string
+	pkg  *types.Package
+}
+
+func (imp *sourceImporter) Import(path string) (*types.Package, error) {
+	if path != imp.path {
+		return importer.Default().Import(path)
+	}
+	if imp.pkg != nil {
+		return imp.pkg, nil
+	}
+
+	pkgs, err := parser.ParseDir(imp.fset, imp.dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		return nil, err
+	}
+	var files []*ast.File
+	for _, pkg := range pkgs {
+		for _, f := range pkg.Files {
+			files = append(files, f)
+		}
+	}
+
+	conf := types.Config{Importer: imp}
+	imp.pkg, err = conf.Check(path, imp.fset, files, nil)
+	return imp.pkg, err
+}
+
+// typeCheck type-checks src, a file of generated code.
+func typeCheck(imp *sourceImporter, src []byte) error {
+	f, err := parser.ParseFile(imp.fset, "generated.go", src, 0)
+	if err != nil {
+		return err
+	}
+	conf := types.Config{Importer: imp}
+	_, err = conf.Check(f.Name.Name, imp.fset, []*ast.File{f}, nil)
+	return err
+}
+
+func TestGenerate(t *testing.T) {
+	imp := &sourceImporter{fset: token.NewFileSet(), path: "github.com/hayamiz/go-projson", dir: filepath.Join("..", "..")}
+
+	for _, name := range []string{"user", "points"} {
+		src, err := ioutil.ReadFile(filepath.Join("testdata", name+".json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		typeName := upperFirst(name)
+		out, err := generate(src, typeName, "model", "github.com/hayamiz/go-projson")
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		actual := string(out)
+		if err := typeCheck(imp, out); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+
+		golden := filepath.Join("testdata", name+".go.golden")
+		if *update {
+			if err := ioutil.WriteFile(golden, out, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		expected, err := ioutil.ReadFile(golden)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != string(expected) {
+			t.Errorf("%s\nexpected: %v\nactual: %v", name, string(expected), actual)
+		}
+	}
+}
+
+func TestGenerateError(t *testing.T) {
+	cases := []struct {
+		schema string
+		err    string
+	}{
+		{`{"type": "string"}`, "the schema is not of an object or an array"},
+		{`{"type": "array"}`, "unsupported schema at #: array without items"},
+		{`{"type": "object", "properties": {"a": {"type": ["string", "integer"]}}}`, "unsupported schema at #/properties/a: more than one type"},
+		{`{"type": "object", "properties": {"a": {"enum": [1, 2]}}}`, "unsupported schema at #/properties/a: enum"},
+		{`{"type": "object", "properties": {"a": {"type": "string", "enum": ["x"]}}}`, "unsupported schema at #/properties/a: enum"},
+		{`{"type": "object", "properties": {"a": {}}}`, "unsupported schema at #/properties/a: no type"},
+		{`{"type": "object", "properties": {"a-b": {"type": "string"}, "a_b": {"type": "string"}}}`, "unsupported schema at #: properties named AB in Go"},
+		{`{"type": "array", "items": true}`, "unsupported schema at #/items: not an object"},
+	}
+
+	for _, c := range cases {
+		_, err := generate([]byte(c.schema), "T", "main", "projson")
+		if err == nil || err.Error() != c.err {
+			t.Errorf("%s\nexpected: %v\nactual: %v", c.schema, c.err, err)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package projson
+
+import (
+	"errors"
+)
+
+// Emitter renders what is put to a JsonPrinter. The printer checks that
+// its methods are called in a valid order and passes them on as events:
+// Key precedes each member of an object, and BeginArray and BeginObject
+// are given the number of members, or -1 if it is not known in advance.
+type Emitter interface {
+	BeginArray(count int) error
+	EndArray() error
+	BeginObject(count int) error
+	EndObject() error
+	Key(key string) error
+	Scalar(s Scalar) error
+}
+
+type ScalarKind int
+
+const (
+	IntScalar ScalarKind = iota
+	FloatScalar
+	StringScalar
+	BoolScalar
+	NullScalar
+)
+
+// Scalar is a value put to a printer, as JSON text and as a Go value.
+type Scalar struct {
+	Kind    ScalarKind
+	Literal string
+	Value   interface{} // int64, float64, string, bool or nil, and uint64 above math.MaxInt64
+
+	color int    // overrides the color of Kind if not 0
+	bytes []byte // put by PutBytes, which binary formats write as they are
+}
+
+func (s Scalar) colorcode() int {
+	if s.color != 0 {
+		return s.color
+	}
+
+	switch s.Kind {
+	case IntScalar:
+		return colorInt
+	case FloatScalar:
+		return colorFloat
+	case StringScalar:
+		return colorString
+	case BoolScalar:
+		return colorBool
+	case NullScalar:
+		return colorNull
+	}
+	return colorNormal
+}
+
+func (printer *JsonPrinter) SetEmitter(emitter Emitter) error {
+	if printer.err != nil {
+		return printer.err
+	}
+
+	if printer.state != stateInit {
+		printer.err = errors.New("Emitter cannot changed after putting some items")
+		return printer.err
+	}
+
+	printer.emitter = emitter
+	return nil
+}
+
+// AsEmitter returns an emitter rendering to printer in its style and
+// format, e.g. to be a target of Tee.
+func (printer *JsonPrinter) AsEmitter() Emitter {
+	switch {
+	case printer.canonical:
+		return &canonicalEmitter{printer: printer}
+	case printer.format == CBORFormat:
+		return &cborEmitter{printer: printer}
+	case printer.buffered():
+		return &treeEmitter{printer: printer}
+	}
+	return &simpleEmitter{printer: printer}
+}
+
+// out returns the emitter events are passed on to, through the schema
+// validator if there is one.
+func (printer *JsonPrinter) out() Emitter {
+	if printer.validator != nil {
+		printer.validator.next = printer.target()
+		return printer.validator
+	}
+	return printer.target()
+}
+
+// target returns the emitter rendering events.
+func (printer *JsonPrinter) target() Emitter {
+	if printer.emitter != nil {
+		return printer.emitter
+	}
+	if printer.builtin == nil {
+		printer.builtin = printer.AsEmitter()
+	}
+	return printer.builtin
+}
+
+// Tee returns an emitter passing events on to each of emitters in turn,
+// up to the first error.
+func Tee(emitters ...Emitter) Emitter {
+	return teeEmitter(emitters)
+}
+
+type teeEmitter []Emitter
+
+func (tee teeEmitter) each(event func(Emitter) error) error {
+	for _, emitter := range tee {
+		if err := event(emitter); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (tee teeEmitter) BeginArray(count int) error {
+	return tee.each(func(e Emitter) error { return e.BeginArray(count) })
+}
+
+func (tee teeEmitter) EndArray() error {
+	return tee.each(func(e Emitter) error { return e.EndArray() })
+}
+
+func (tee teeEmitter) BeginObject(count int) error {
+	return tee.each(func(e Emitter) error { return e.BeginObject(count) })
+}
+
+func (tee teeEmitter) EndObject() error {
+	return tee.each(func(e Emitter) error { return e.EndObject() })
+}
+
+func (tee teeEmitter) Key(key string) error {
+	return tee.each(func(e Emitter) error { return e.Key(key) })
+}
+
+func (tee teeEmitter) Scalar(s Scalar) error {
+	return tee.each(func(e Emitter) error { return e.Scalar(s) })
+}
+
+// simpleEmitter writes SimpleStyle JSON as events come. Without color,
+// scalars are appended to the buffer right from Put* (see fastScalar).
+type simpleEmitter struct {
+	printer  *JsonPrinter
+	members  []int    // number of members of each open container
+	keyed    bool     // a key is waiting for its value
+	comments []string // put after the separator of what comes next
+}
+
+func (e *simpleEmitter) write(text string, colorcode int) {
+	if e.printer.color && colorcode != colorNormal {
+		e.printer.buffer.WriteString(e.printer.paint(text, colorcode))
+	} else {
+		e.printer.buffer.WriteString(text)
+	}
+}
+
+// avail returns the unused capacity of the buffer, of at least n bytes,
+// to append to and write back.
+func (e *simpleEmitter) avail(n int) []byte {
+	e.printer.buffer.Grow(n)
+	return e.printer.buffer.AvailableBuffer()
+}
+
+// next writes the separator before a key or a value, and the comments
+// put before it.
+func (e *simpleEmitter) next() {
+	if e.keyed {
+		e.keyed = false
+	} else if top := len(e.members) - 1; top >= 0 {
+		if e.members[top] > 0 {
+			e.printer.buffer.WriteByte(',')
+		}
+		e.members[top]++
+	}
+
+	if len(e.comments) > 0 {
+		e.flushComments()
+	}
+}
+
+func (e *simpleEmitter) flushComments() {
+	for _, text := range e.comments {
+		e.write(blockComment(text), colorComment)
+	}
+	e.comments = e.comments[:0]
+}
+
+func (e *simpleEmitter) begin(opener string) error {
+	e.next()
+	e.printer.buffer.WriteString(opener)
+	e.members = append(e.members, 0)
+	return nil
+}
+
+func (e *simpleEmitter) end(closer string) error {
+	e.flushComments()
+	e.members = e.members[:len(e.members)-1]
+	e.printer.buffer.WriteString(closer)
+	return nil
+}
+
+func (e *simpleEmitter) BeginArray(count int) error {
+	return e.begin("[")
+}
+
+func (e *simpleEmitter) EndArray() error {
+	return e.end("]")
+}
+
+func (e *simpleEmitter) BeginObject(count int) error {
+	return e.begin("{")
+}
+
+func (e *simpleEmitter) EndObject() error {
+	return e.end("}")
+}
+
+func (e *simpleEmitter) Key(key string) error {
+	e.next()
+	if e.printer.color || e.printer.json5Option(JSON5UnquotedKeys) {
+		quoted, err := e.printer.quoteKey(key)
+		if err != nil {
+			return err
+		}
+		e.write(quoted, colorKey)
+	} else {
+		buf, err := e.printer.appendQuoted(e.avail(len(key)+3), key)
+		if err != nil {
+			return err
+		}
+		e.printer.buffer.Write(buf)
+	}
+
+	e.printer.buffer.WriteByte(':')
+	e.keyed = true
+	return nil
+}
+
+func (e *simpleEmitter) Scalar(s Scalar) error {
+	e.next()
+	e.write(s.Literal, s.colorcode())
+	return nil
+}
@@ -0,0 +1,91 @@
+package projson
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// eventLog is an emitter listing the events it receives.
+type eventLog struct {
+	events []string
+	fail   string // event to fail on
+}
+
+func (l *eventLog) add(event string) error {
+	l.events = append(l.events, event)
+	if event == l.fail {
+		return errors.New("failed at " + event)
+	}
+	return nil
+}
+
+func (l *eventLog) BeginArray(count int) error  { return l.add(fmt.Sprintf("[%d", count)) }
+func (l *eventLog) EndArray() error             { return l.add("]") }
+func (l *eventLog) BeginObject(count int) error { return l.add(fmt.Sprintf("{%d", count)) }
+func (l *eventLog) EndObject() error            { return l.add("}") }
+func (l *eventLog) Key(key string) error        { return l.add("key " + key) }
+func (l *eventLog) Scalar(s Scalar) error {
+	return l.add(fmt.Sprintf("%d %s %v", s.Kind, s.Literal, s.Value))
+}
+
+func TestEmitterEvents(t *testing.T) {
+	log := &eventLog{}
+	jp := NewPrinter()
+	jp.SetEmitter(log)
+
+	jp.Obj().
+		Key("a").Arr().Int(1).Float(2.5).End().
+		Key("b").Array([]interface{}{"x"}).
+		End()
+
+	expected := "{-1|key a|[-1|0 1 1|1 2.5 2.5|]|key b|[1|2 \"x\" x|]|}"
+	if actual := strings.Join(log.events, "|"); expected != actual {
+		t.Errorf("expected: %v\nactual: %v", expected, actual)
+	}
+	if actual, err := jp.String(); err != nil || actual != "" {
+		t.Errorf("expected no output, actual: %v (%v)", actual, err)
+	}
+}
+
+func TestEmitterError(t *testing.T) {
+	log := &eventLog{fail: "key b"}
+	jp := NewPrinter()
+	jp.SetEmitter(log)
+
+	jp.Obj().Key("a").Int(1).Key("b").Int(2).End()
+	if err := jp.Error(); err == nil || err.Error() != "failed at key b" {
+		t.Errorf("expected: failed at key b\nactual: %v", err)
+	}
+	if len(log.events) != 4 {
+		t.Errorf("events after the error: %v", log.events)
+	}
+
+	if err := NewPrinter().Int(1).SetEmitter(log); err == nil {
+		t.Errorf("emitter must not be changed after putting items")
+	}
+}
+
+func TestTee(t *testing.T) {
+	compact := NewPrinter()
+	pretty := NewPrinter()
+	pretty.SetStyle(PrettyStyle)
+	pretty.SetTermWidth(10)
+	log := &eventLog{}
+
+	jp := NewPrinter()
+	jp.SetEmitter(Tee(compact.AsEmitter(), pretty.AsEmitter(), log))
+	jp.Obj().Key("key").Arr().Int(1).Int(2).End().End()
+
+	if actual, _ := compact.String(); actual != `{"key":[1,2]}` {
+		t.Errorf("expected: %v\nactual: %v", `{"key":[1,2]}`, actual)
+	}
+	expected := "{\n  \"key\": [\n    1,\n    2\n  ]\n}"
+	if actual, _ := pretty.String(); actual != expected {
+		t.Errorf("expected: %v\nactual: %v", expected, actual)
+	}
+	if len(log.events) != 7 {
+		t.Errorf("expected 7 events, actual: %v", log.events)
+	}
+}
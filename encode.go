@@ -0,0 +1,692 @@
+package projson
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PutValue puts a Go value by reflection: booleans, numbers and strings as
+// scalars, nil pointers, interfaces, maps and slices as null, slices and
+// arrays as arrays, maps with string, integer or encoding.TextMarshaler
+// keys as objects in the order of their keys, and other values
+// implementing encoding.TextMarshaler as strings. time.Time,
+// time.Duration, []byte, net.IP and url.URL are put as by PutTime,
+// PutDuration, PutBytes, PutIP and PutURL, except that an empty net.IP is
+// put as "", like encoding/json does.
+//
+// Structs are put as objects of their exported fields, named and
+// formatted by the projson struct tag, or the name of the json tag if it
+// has none. The name may be followed by options:
+//
+//	Name    string  `projson:"name,priority=1"`    // put before the other fields
+//	Score   float64 `projson:"score,fmt=%.2f"`     // formatted by fmt.Sprintf
+//	Level   string  `projson:"level,color=yellow"` // in yellow in colored output
+//	ID      int64   `projson:"id,string"`          // put as a string "123"
+//	Note    string  `projson:"note,omitzero"`      // omitted if zero
+//	Meta    Meta    `projson:"meta,inline"`        // fields put in the enclosing object
+//	Ignored int     `projson:"-"`
+//
+// Fields are put in the order of priority, highest first, and then in
+// the order of declaration. Fields of embedded structs are inlined if
+// their tag gives no name, and fields of an enclosing struct hide inlined
+// fields of the same name. omitempty omits false, 0, nil, and empty
+// strings, slices and maps. fmt applies to floats, string to numbers and
+// booleans, and color to them and strings, including the elements of
+// slices and arrays; elsewhere they are errors, except that color also
+// replaces the colors of PutTime and the like. The colors are bold,
+// black, red, green, yellow, blue, magenta, cyan, white and gray.
+func (printer *JsonPrinter) PutValue(v interface{}) error {
+	if printer.err != nil {
+		return printer.err
+	}
+
+	if v == nil {
+		return printer.PutNull()
+	}
+
+	rv := reflect.ValueOf(v)
+	return encoderOf(rv.Type())(printer, rv)
+}
+
+// maxValueDepth is the nesting depth at which PutValue takes the value
+// to be cyclic.
+const maxValueDepth = 1000
+
+type encoder func(printer *JsonPrinter, v reflect.Value) error
+
+var encoders sync.Map // reflect.Type -> encoder
+
+var (
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	timeType          = reflect.TypeOf(time.Time{})
+	durationType      = reflect.TypeOf(time.Duration(0))
+	ipType            = reflect.TypeOf(net.IP{})
+	urlType           = reflect.TypeOf(url.URL{})
+)
+
+// encoderOf returns the encoder of values of t, built on first use.
+func encoderOf(t reflect.Type) encoder {
+	if e, ok := encoders.Load(t); ok {
+		return e.(encoder)
+	}
+
+	// recursive types get this encoder while their own is built
+	var wg sync.WaitGroup
+	var e encoder
+	wg.Add(1)
+	indirect, loaded := encoders.LoadOrStore(t, encoder(func(printer *JsonPrinter, v reflect.Value) error {
+		wg.Wait()
+		return e(printer, v)
+	}))
+	if loaded {
+		return indirect.(encoder)
+	}
+
+	e = newEncoder(t, nil)
+	wg.Done()
+	encoders.Store(t, e)
+	return e
+}
+
+// newEncoder returns an encoder of values of t. Scalars and their
+// pointers, slices and arrays are formatted with opts, if not nil.
+func newEncoder(t reflect.Type, opts *valueOptions) encoder {
+	switch t {
+	case timeType:
+		colorcode := opts.colorOr(colorTime)
+		return func(printer *JsonPrinter, v reflect.Value) error {
+			return printer.putTime(v.Interface().(time.Time), colorcode)
+		}
+	case durationType:
+		colorcode := opts.colorOr(colorDuration)
+		return func(printer *JsonPrinter, v reflect.Value) error {
+			return printer.putDuration(time.Duration(v.Int()), colorcode)
+		}
+	case ipType:
+		colorcode := opts.colorOr(colorIP)
+		return func(printer *JsonPrinter, v reflect.Value) error {
+			ip := v.Interface().(net.IP)
+			if len(ip) == 0 {
+				// "" rather than null, as with encoding/json
+				return printer.putColored(Scalar{Kind: StringScalar, Value: ""}, colorcode)
+			}
+			return printer.putIP(ip, colorcode)
+		}
+	case urlType:
+		colorcode := opts.colorOr(colorURL)
+		return func(printer *JsonPrinter, v reflect.Value) error {
+			u := v.Interface().(url.URL)
+			return printer.putURL(&u, colorcode)
+		}
+	}
+
+	if t.Implements(textMarshalerType) && (t.Kind() != reflect.Ptr || !t.Elem().Implements(textMarshalerType)) {
+		return encodeText
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return opts.encodeBool
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return opts.encodeInt
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return opts.encodeUint
+	case reflect.Float32, reflect.Float64:
+		return opts.encodeFloat
+	case reflect.String:
+		return opts.encodeString
+	case reflect.Interface:
+		return encodeInterface
+	case reflect.Ptr:
+		return newPtrEncoder(t, opts)
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 && !reflect.PtrTo(t.Elem()).Implements(textMarshalerType) {
+			colorcode := opts.colorOr(colorBytes)
+			return func(printer *JsonPrinter, v reflect.Value) error {
+				return printer.putBytes(v.Bytes(), colorcode)
+			}
+		}
+		return newArrayEncoder(t, opts)
+	case reflect.Array:
+		return newArrayEncoder(t, opts)
+	case reflect.Map:
+		return newMapEncoder(t)
+	case reflect.Struct:
+		return newStructEncoder(t)
+	}
+
+	return func(printer *JsonPrinter, v reflect.Value) error {
+		printer.err = errors.New("Cannot put value of type " + t.String())
+		return printer.err
+	}
+}
+
+// elemEncoder returns the encoder of values of t, formatted with opts.
+// Structs and maps take no options, so that their encoders are cached and
+// recursive types end.
+func elemEncoder(t reflect.Type, opts *valueOptions) encoder {
+	if opts == nil || t.Kind() == reflect.Struct && !valueType(t) || t.Kind() == reflect.Map {
+		return encoderOf(t)
+	}
+	return newEncoder(t, opts)
+}
+
+// nested checks the nesting depth before putting a container.
+func (printer *JsonPrinter) nested() error {
+	if len(printer.pathStack) >= maxValueDepth {
+		printer.err = errors.New("Cannot put value nested too deeply, maybe cyclic")
+		return printer.err
+	}
+	return nil
+}
+
+// valueOptions are the options of a struct tag formatting scalars. Its
+// methods take a nil receiver as no options.
+type valueOptions struct {
+	fmtstr   string // of floats
+	color    int
+	asString bool
+}
+
+// colorOr returns the color of opts, or colorcode if it has none.
+func (opts *valueOptions) colorOr(colorcode int) int {
+	if opts == nil || opts.color == 0 {
+		return colorcode
+	}
+	return opts.color
+}
+
+// put puts s as it is formatted by opts.
+func (opts *valueOptions) put(printer *JsonPrinter, s Scalar) error {
+	if opts.asString && s.Kind != StringScalar && s.Kind != NullScalar {
+		str, err := printer.quote(s.Literal)
+		if err != nil {
+			printer.err = err
+			return printer.err
+		}
+		s = Scalar{Kind: StringScalar, Literal: str, Value: s.Literal}
+	}
+	s.color = opts.color
+	return printer.putScalar(s)
+}
+
+func (opts *valueOptions) encodeBool(printer *JsonPrinter, v reflect.Value) error {
+	if opts == nil {
+		return printer.PutBool(v.Bool())
+	}
+	return opts.put(printer, Scalar{Kind: BoolScalar, Literal: strconv.FormatBool(v.Bool()), Value: v.Bool()})
+}
+
+func (opts *valueOptions) encodeInt(printer *JsonPrinter, v reflect.Value) error {
+	if opts == nil {
+		return printer.PutInt64(v.Int())
+	}
+
+	str := printer.formatInt(v.Int())
+	if opts.asString {
+		str = strconv.FormatInt(v.Int(), 10)
+	}
+	return opts.put(printer, Scalar{Kind: IntScalar, Literal: str, Value: v.Int()})
+}
+
+func (opts *valueOptions) encodeUint(printer *JsonPrinter, v reflect.Value) error {
+	u := v.Uint()
+	if u <= math.MaxInt64 {
+		return opts.encodeInt(printer, reflect.ValueOf(int64(u)))
+	}
+
+	str := printer.formatUint(u)
+	if opts != nil && opts.asString {
+		str = strconv.FormatUint(u, 10)
+	}
+	s := Scalar{Kind: IntScalar, Literal: str, Value: u}
+	if opts == nil {
+		return printer.putScalar(s)
+	}
+	return opts.put(printer, s)
+}
+
+func (opts *valueOptions) encodeFloat(printer *JsonPrinter, v reflect.Value) error {
+	f := v.Float()
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		printer.err = errors.New("Cannot put " + strconv.FormatFloat(f, 'g', -1, 64))
+		return printer.err
+	}
+
+	bits := 64
+	if v.Kind() == reflect.Float32 {
+		bits = 32
+	}
+
+	if opts == nil {
+		if bits == 64 {
+			return printer.PutFloat(f)
+		}
+		return printer.putScalar(Scalar{Kind: FloatScalar, Literal: strconv.FormatFloat(f, 'f', -1, 32), Value: f})
+	}
+
+	str := strconv.FormatFloat(f, 'f', -1, bits)
+	if opts.fmtstr != "" {
+		str = fmt.Sprintf(opts.fmtstr, f)
+	}
+	return opts.put(printer, Scalar{Kind: FloatScalar, Literal: str, Value: f})
+}
+
+func (opts *valueOptions) encodeString(printer *JsonPrinter, v reflect.Value) error {
+	if opts == nil || opts.color == 0 {
+		return printer.PutString(v.String())
+	}
+
+	s, err := printer.stringScalar(v.String())
+	if err != nil {
+		return err
+	}
+	return opts.put(printer, s)
+}
+
+func encodeText(printer *JsonPrinter, v reflect.Value) error {
+	if v.Kind() == reflect.Ptr && v.IsNil() {
+		return printer.PutNull()
+	}
+
+	text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+	if err != nil {
+		printer.err = err
+		return printer.err
+	}
+	return printer.PutString(string(text))
+}
+
+func encodeInterface(printer *JsonPrinter, v reflect.Value) error {
+	if v.IsNil() {
+		return printer.PutNull()
+	}
+	return encoderOf(v.Elem().Type())(printer, v.Elem())
+}
+
+func newPtrEncoder(t reflect.Type, opts *valueOptions) encoder {
+	elem := elemEncoder(t.Elem(), opts)
+	return func(printer *JsonPrinter, v reflect.Value) error {
+		if v.IsNil() {
+			return printer.PutNull()
+		}
+		if err := printer.nested(); err != nil {
+			return err
+		}
+		return elem(printer, v.Elem())
+	}
+}
+
+func newArrayEncoder(t reflect.Type, opts *valueOptions) encoder {
+	elem := elemEncoder(t.Elem(), opts)
+	return func(printer *JsonPrinter, v reflect.Value) error {
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return printer.PutNull()
+		}
+		if err := printer.nested(); err != nil {
+			return err
+		}
+
+		n := v.Len()
+		if err := printer.beginArray(n); err != nil {
+			return err
+		}
+		for i := 0; i < n; i++ {
+			if err := elem(printer, v.Index(i)); err != nil {
+				return err
+			}
+		}
+		return printer.FinishArray()
+	}
+}
+
+func newMapEncoder(t reflect.Type) encoder {
+	var keyOf func(k reflect.Value) (string, error)
+	switch {
+	case t.Key().Kind() == reflect.String:
+		keyOf = func(k reflect.Value) (string, error) { return k.String(), nil }
+	case t.Key().Implements(textMarshalerType):
+		keyOf = func(k reflect.Value) (string, error) {
+			text, err := k.Interface().(encoding.TextMarshaler).MarshalText()
+			return string(text), err
+		}
+	default:
+		switch t.Key().Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			keyOf = func(k reflect.Value) (string, error) { return strconv.FormatInt(k.Int(), 10), nil }
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			keyOf = func(k reflect.Value) (string, error) { return strconv.FormatUint(k.Uint(), 10), nil }
+		default:
+			return func(printer *JsonPrinter, v reflect.Value) error {
+				printer.err = errors.New("Cannot put map with key of type " + t.Key().String())
+				return printer.err
+			}
+		}
+	}
+
+	elem := encoderOf(t.Elem())
+	return func(printer *JsonPrinter, v reflect.Value) error {
+		if v.IsNil() {
+			return printer.PutNull()
+		}
+		if err := printer.nested(); err != nil {
+			return err
+		}
+
+		type member struct {
+			key   string
+			value reflect.Value
+		}
+		members := make([]member, 0, v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			key, err := keyOf(iter.Key())
+			if err != nil {
+				printer.err = err
+				return printer.err
+			}
+			members = append(members, member{key, iter.Value()})
+		}
+		sort.Slice(members, func(i, j int) bool { return members[i].key < members[j].key })
+
+		if err := printer.beginObject(len(members)); err != nil {
+			return err
+		}
+		for _, m := range members {
+			if err := printer.PutKey(m.key); err != nil {
+				return err
+			}
+			if err := elem(printer, m.value); err != nil {
+				return err
+			}
+		}
+		return printer.FinishObject()
+	}
+}
+
+const (
+	omitNever = iota
+	omitZero
+	omitEmpty
+)
+
+// structField is a field of the plan of a struct type.
+type structField struct {
+	name     string
+	index    []int // of the field, in inlined structs
+	encode   encoder
+	omit     int
+	priority int
+	depth    int // of inlining
+}
+
+func newStructEncoder(t reflect.Type) encoder {
+	fields, err := structPlan(t)
+	if err != nil {
+		return func(printer *JsonPrinter, v reflect.Value) error {
+			printer.err = err
+			return printer.err
+		}
+	}
+
+	return func(printer *JsonPrinter, v reflect.Value) error {
+		if err := printer.nested(); err != nil {
+			return err
+		}
+		if err := printer.BeginObject(); err != nil {
+			return err
+		}
+
+	fields:
+		for i := range fields {
+			f := &fields[i]
+			fv := v
+			for _, index := range f.index {
+				if fv.Kind() == reflect.Ptr {
+					if fv.IsNil() {
+						continue fields // in a nil inlined struct
+					}
+					fv = fv.Elem()
+				}
+				fv = fv.Field(index)
+			}
+
+			if f.omit == omitZero && fv.IsZero() || f.omit == omitEmpty && isEmptyValue(fv) {
+				continue
+			}
+
+			if err := printer.PutKey(f.name); err != nil {
+				return err
+			}
+			if err := f.encode(printer, fv); err != nil {
+				return err
+			}
+		}
+
+		return printer.FinishObject()
+	}
+}
+
+// structPlan returns the fields of the struct type t in the order they
+// are put.
+func structPlan(t reflect.Type) ([]structField, error) {
+	fields := []structField{}
+	if err := collectFields(t, nil, map[reflect.Type]bool{}, &fields); err != nil {
+		return nil, err
+	}
+
+	// outer fields hide inlined ones
+	depths := map[string]int{}
+	for _, f := range fields {
+		if d, ok := depths[f.name]; !ok || f.depth < d {
+			depths[f.name] = f.depth
+		}
+	}
+	visible := fields[:0]
+	for _, f := range fields {
+		if f.depth == depths[f.name] {
+			visible = append(visible, f)
+			depths[f.name] = -1 // the first of the same depth only
+		}
+	}
+
+	sort.SliceStable(visible, func(i, j int) bool { return visible[i].priority > visible[j].priority })
+	return visible, nil
+}
+
+func collectFields(t reflect.Type, index []int, inlining map[reflect.Type]bool, fields *[]structField) error {
+	inlining[t] = true
+	defer delete(inlining, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" && !sf.Anonymous {
+			continue // unexported
+		}
+
+		tag, ok := sf.Tag.Lookup("projson")
+		fromJSON := !ok
+		if fromJSON {
+			tag = sf.Tag.Get("json")
+		}
+		if tag == "-" {
+			continue
+		}
+
+		name, opts := tag, ""
+		if i := strings.Index(tag, ","); i >= 0 {
+			name, opts = tag[:i], tag[i+1:]
+		}
+
+		f := structField{name: name, index: append(append([]int{}, index...), i), depth: len(index)}
+		var vopts valueOptions
+		target := optionTarget(sf.Type)
+		inline := sf.Anonymous && name == ""
+		for _, opt := range strings.Split(opts, ",") {
+			var err error
+			key, value := opt, ""
+			if i := strings.Index(opt, "="); i >= 0 {
+				key, value = opt[:i], opt[i+1:]
+			}
+
+			switch key {
+			case "":
+			case "omitzero":
+				f.omit = omitZero
+			case "omitempty":
+				f.omit = omitEmpty
+			case "inline":
+				inline = true
+			case "string":
+				if err = optionApplies(key, target); err == nil {
+					vopts.asString = true
+				}
+			case "fmt":
+				if err = optionApplies(key, target); err == nil {
+					vopts.fmtstr = value
+				}
+			case "priority":
+				f.priority, err = strconv.Atoi(value)
+			case "color":
+				colorcode, ok := colorNames[value]
+				if !ok {
+					err = errors.New("unknown color")
+				} else if err = optionApplies(key, target); err == nil {
+					vopts.color = colorcode
+				}
+			default:
+				err = errors.New("unknown option")
+			}
+
+			if err != nil {
+				if fromJSON {
+					continue // json options projson has no use of
+				}
+				return errors.New("Invalid projson tag option " + opt + " of " + t.String() + "." + sf.Name + ": " + err.Error())
+			}
+		}
+
+		ft := sf.Type
+		if ft.Kind() == reflect.Ptr && ft.Name() == "" {
+			ft = ft.Elem()
+		}
+		if inline && ft.Kind() == reflect.Struct {
+			if inlining[ft] {
+				return errors.New("Cannot inline " + ft.String() + " in itself")
+			}
+			if err := collectFields(ft, f.index, inlining, fields); err != nil {
+				return err
+			}
+			continue
+		}
+		if sf.PkgPath != "" {
+			continue // unexported non-struct embedded
+		}
+
+		if f.name == "" {
+			f.name = sf.Name
+		}
+		if vopts != (valueOptions{}) {
+			f.encode = elemEncoder(sf.Type, &vopts)
+		} else {
+			f.encode = encoderOf(sf.Type)
+		}
+		*fields = append(*fields, f)
+	}
+
+	return nil
+}
+
+// valueType reports whether values of t are put by a Put method of their
+// own, like PutTime.
+func valueType(t reflect.Type) bool {
+	switch t {
+	case timeType, durationType, ipType, urlType:
+		return true
+	}
+	return false
+}
+
+// optionTarget returns the type of the values that fmt, color and string
+// options of a field of type t format: t, or its elements.
+func optionTarget(t reflect.Type) reflect.Type {
+	for {
+		switch t.Kind() {
+		case reflect.Ptr, reflect.Array:
+			t = t.Elem()
+		case reflect.Slice:
+			if t.Elem().Kind() == reflect.Uint8 {
+				return t // put as a string
+			}
+			t = t.Elem()
+		default:
+			return t
+		}
+	}
+}
+
+// optionApplies returns an error if the option key cannot format values
+// of t.
+func optionApplies(key string, t reflect.Type) error {
+	ok := false
+	if valueType(t) || t.Kind() == reflect.Slice {
+		ok = key == "color" // of a Put method of their own
+	} else if !t.Implements(textMarshalerType) {
+		switch t.Kind() {
+		case reflect.Float32, reflect.Float64:
+			ok = true
+		case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			ok = key != "fmt"
+		case reflect.String:
+			ok = key == "color"
+		}
+	}
+
+	if !ok {
+		return errors.New("not for values of type " + t.String())
+	}
+	return nil
+}
+
+var colorNames = map[string]int{
+	"bold":    colorBold,
+	"black":   colorBlack,
+	"red":     colorRed,
+	"green":   colorGreen,
+	"yellow":  colorYellow,
+	"blue":    colorBlue,
+	"magenta": colorMagenta,
+	"cyan":    colorCyan,
+	"white":   colorWhite,
+	"gray":    colorGray,
+}
+
+// isEmptyValue reports whether v is omitted by omitempty, as with
+// encoding/json.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,196 @@
+package projson
+
+import (
+	"math"
+	"net"
+	"testing"
+)
+
+type testMeta struct {
+	Source  string `projson:"source"`
+	Version int    `projson:"version"`
+}
+
+type testBase struct {
+	ID      int64  `projson:"id,string,priority=10"`
+	Created string `projson:"created"`
+}
+
+type testRecord struct {
+	testBase
+	Name    string            `projson:"name,priority=5"`
+	Score   float64           `projson:"score,fmt=%.2f"`
+	Scores  []float64         `projson:"scores,fmt=%.1f"`
+	Level   string            `projson:"level,color=yellow"`
+	Note    string            `projson:"note,omitzero"`
+	Tags    []string          `json:"tags,omitempty"`
+	Meta    *testMeta         `projson:",inline"`
+	Counts  map[string]uint64 `projson:"counts"`
+	Raw     []byte            `projson:"raw"`
+	Addr    net.IP            `projson:"addr"`
+	Any     interface{}       `projson:"any"`
+	Next    *testRecord       `projson:"next,omitempty"`
+	Created string            `projson:"created"`
+	Ignored int               `projson:"-"`
+	hidden  int
+	Extra   map[int]testMeta32 `projson:"extra,omitempty"`
+}
+
+type testMeta32 struct {
+	Ratio float32
+}
+
+func TestPutValue(t *testing.T) {
+	record := testRecord{
+		testBase: testBase{ID: 42, Created: "hidden"},
+		Name:     "alice",
+		Score:    1.0 / 3,
+		Scores:   []float64{0.25, 2},
+		Level:    "warn",
+		Meta:     &testMeta{Source: "db", Version: 2},
+		Counts:   map[string]uint64{"b": math.MaxUint64, "a": 1},
+		Raw:      []byte("hi"),
+		Addr:     net.IPv4(127, 0, 0, 1),
+		Any:      []interface{}{true, nil},
+		Next:     &testRecord{Name: "bob"},
+		Created:  "today",
+		Extra:    map[int]testMeta32{10: {0.1}, 9: {1}},
+	}
+
+	jp := NewPrinter()
+	if err := jp.PutValue(record); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"id":"42","name":"alice","score":0.33,"scores":[0.2,2.0],"level":"warn",` +
+		`"source":"db","version":2,"counts":{"a":1,"b":18446744073709551615},"raw":"aGk=","addr":"127.0.0.1",` +
+		`"any":[true,null],` +
+		`"next":{"id":"0","name":"bob","score":0.00,"scores":null,"level":"","counts":null,"raw":null,"addr":"","any":null,"created":""},` +
+		`"created":"today","extra":{"10":{"Ratio":0.1},"9":{"Ratio":1}}}`
+	if actual, _ := jp.String(); actual != expected {
+		t.Errorf("expected: %v\nactual: %v", expected, actual)
+	}
+}
+
+func TestPutValueColor(t *testing.T) {
+	jp := NewPrinter()
+	jp.SetColor(true)
+	jp.PutValue(struct {
+		Level string  `projson:"level,color=yellow"`
+		Count float64 `projson:"count,color=red,fmt=%.1f"`
+		Name  string
+	}{"warn", 3, "x"})
+
+	expected := "{" + color(`"level"`, colorKey) + ":" + color(`"warn"`, colorYellow) + "," +
+		color(`"count"`, colorKey) + ":" + color("3.0", colorRed) + "," +
+		color(`"Name"`, colorKey) + ":" + color(`"x"`, colorString) + "}"
+	if actual, _ := jp.String(); actual != expected {
+		t.Errorf("expected: %v\nactual: %v", expected, actual)
+	}
+}
+
+func TestPutValueError(t *testing.T) {
+	type cyclic struct {
+		Next *cyclic
+	}
+	type tree struct {
+		Kids []*tree `projson:"kids,color=red"`
+	}
+	loop := &cyclic{}
+	loop.Next = loop
+
+	for _, v := range []interface{}{
+		make(chan int),
+		map[float64]int{1: 1},
+		math.NaN(),
+		struct {
+			A int `projson:"a,unknown"`
+		}{},
+		struct {
+			A int `projson:"a,color=pink"`
+		}{},
+		struct {
+			A int `projson:"a,priority=high"`
+		}{},
+		struct {
+			A []string `projson:"a,fmt=%.1f"`
+		}{},
+		struct {
+			A string `projson:"a,string"`
+		}{},
+		tree{Kids: []*tree{{}}},
+		loop,
+	} {
+		jp := NewPrinter()
+		if err := jp.PutValue(v); err == nil {
+			t.Errorf("%T\nexpected: error\nactual: nil", v)
+		}
+	}
+
+	// unknown options of json tags are not errors
+	jp := NewPrinter()
+	jp.PutValue(struct {
+		A int    `json:"a,format:x"`
+		B string `json:"b,string"`
+	}{1, "x"})
+	if actual, err := jp.String(); err != nil || actual != `{"a":1,"b":"x"}` {
+		t.Errorf("expected: %v\nactual: %v %v", `{"a":1,"b":"x"}`, actual, err)
+	}
+
+	// options of fields leading back to their struct
+	type node struct {
+		Weights []float64 `projson:"weights,fmt=%.1f"`
+		Kids    []*node   `projson:"kids,omitempty"`
+	}
+	jp = NewPrinter()
+	jp.PutValue(node{Kids: []*node{{Weights: []float64{1}}}})
+	if actual, err := jp.String(); err != nil || actual != `{"weights":null,"kids":[{"weights":[1.0]}]}` {
+		t.Errorf("expected: %v\nactual: %v %v", `{"weights":null,"kids":[{"weights":[1.0]}]}`, actual, err)
+	}
+}
+
+func BenchmarkPutValue(b *testing.B) {
+	record := testRecord{Name: "alice", Score: 1.5, Scores: []float64{1, 2, 3}, Meta: &testMeta{Source: "db"}}
+	jp := NewPrinter()
+	jp.BeginArray()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		jp.PutValue(&record)
+	}
+	jp.FinishArray()
+}
+
+func TestPutValueUint64(t *testing.T) {
+	max := uint64(math.MaxUint64)
+
+	cases := []struct {
+		format   int
+		expected string
+	}{
+		{JSONFormat, "18446744073709551615"},
+		{CBORFormat, "\x1b\xff\xff\xff\xff\xff\xff\xff\xff"},
+		{MessagePackFormat, "\xcf\xff\xff\xff\xff\xff\xff\xff\xff"},
+	}
+
+	for _, c := range cases {
+		jp := NewPrinter()
+		jp.SetFormat(c.format)
+		jp.PutValue(max)
+		if actual, err := jp.Bytes(); err != nil || string(actual) != c.expected {
+			t.Errorf("expected: %q\nactual: %q %v", c.expected, actual, err)
+		}
+	}
+
+	schema, _ := ParseSchema([]byte(`{"type": "integer", "minimum": 0}`))
+	jp := NewPrinter()
+	jp.SetSchema(schema)
+	if err := jp.PutValue(max); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	jp = NewPrinter()
+	jp.SetFormat(TOMLFormat)
+	if err := jp.PutValue(map[string]uint64{"a": max}); err == nil {
+		t.Errorf("expected: error\nactual: nil")
+	}
+}
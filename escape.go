@@ -0,0 +1,170 @@
+package projson
+
+import (
+	"errors"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Strings and keys are escaped like encoding/json does by default. HTML
+// escaping of <, > and & can be turned off, non-ASCII characters can be
+// escaped as \uXXXX (with surrogate pairs beyond the BMP), and invalid
+// UTF-8 can be rejected instead of replaced with U+FFFD.
+
+func (printer *JsonPrinter) SetEscapeHTML(escape bool) error {
+	if printer.err != nil {
+		return printer.err
+	}
+
+	if printer.state != stateInit {
+		printer.err = errors.New("HTML escaping cannot changed after putting some items")
+		return printer.err
+	}
+
+	printer.escapeHTML = escape
+	return nil
+}
+
+func (printer *JsonPrinter) SetASCIIOnly(ascii bool) error {
+	if printer.err != nil {
+		return printer.err
+	}
+
+	if printer.state != stateInit {
+		printer.err = errors.New("ASCII only mode cannot changed after putting some items")
+		return printer.err
+	}
+
+	printer.asciiOnly = ascii
+	return nil
+}
+
+// SetStrictUTF8 makes putting strings and keys with invalid UTF-8 an
+// error, instead of replacing each invalid byte with U+FFFD.
+func (printer *JsonPrinter) SetStrictUTF8(strict bool) error {
+	if printer.err != nil {
+		return printer.err
+	}
+
+	if printer.state != stateInit {
+		printer.err = errors.New("Strict UTF-8 mode cannot changed after putting some items")
+		return printer.err
+	}
+
+	printer.strictUTF8 = strict
+	return nil
+}
+
+// quote returns s as a JSON string, built in a buffer reused across
+// calls.
+func (printer *JsonPrinter) quote(s string) (string, error) {
+	buf, err := printer.appendQuoted(printer.scratch[:0], s)
+	printer.scratch = buf
+	if err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+// quoteLong returns s as a double-quoted string of TOML and YAML, which
+// escape characters beyond the BMP as \UXXXXXXXX, not as surrogate pairs.
+func (printer *JsonPrinter) quoteLong(s string) (string, error) {
+	buf, err := printer.appendString(printer.scratch[:0], s, '"', true)
+	printer.scratch = buf
+	if err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+// appendQuoted appends s as a JSON string to buf.
+func (printer *JsonPrinter) appendQuoted(buf []byte, s string) ([]byte, error) {
+	return printer.appendString(buf, s, printer.quoteMark(), false)
+}
+
+// appendString appends s quoted with mark to buf, with characters beyond
+// the BMP escaped as \UXXXXXXXX if long. s is copied as is up to the next
+// character to escape.
+func (printer *JsonPrinter) appendString(buf []byte, s string, mark byte, long bool) ([]byte, error) {
+	buf = append(buf, mark)
+
+	start := 0
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if b >= 0x20 && b != mark && b != '\\' &&
+				!(printer.escapeHTML && (b == '<' || b == '>' || b == '&')) {
+				i++
+				continue
+			}
+
+			buf = append(buf, s[start:i]...)
+			switch b {
+			case mark, '\\':
+				buf = append(buf, '\\', b)
+			case '\b':
+				buf = append(buf, '\\', 'b')
+			case '\f':
+				buf = append(buf, '\\', 'f')
+			case '\n':
+				buf = append(buf, '\\', 'n')
+			case '\r':
+				buf = append(buf, '\\', 'r')
+			case '\t':
+				buf = append(buf, '\\', 't')
+			default:
+				buf = appendEscapedRune(buf, rune(b))
+			}
+			i++
+			start = i
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			if printer.strictUTF8 {
+				return buf, errors.New("Invalid UTF-8 in string")
+			}
+			buf = append(buf, s[start:i]...)
+			if printer.asciiOnly {
+				buf = appendEscapedRune(buf, utf8.RuneError)
+			} else {
+				buf = append(buf, "\uFFFD"...)
+			}
+		case r == '\u2028' || r == '\u2029' || printer.asciiOnly:
+			buf = append(buf, s[start:i]...)
+			if r1, r2 := utf16.EncodeRune(r); r1 != utf8.RuneError && long {
+				buf = appendLongEscapedRune(buf, r)
+			} else if r1 != utf8.RuneError {
+				buf = appendEscapedRune(appendEscapedRune(buf, r1), r2)
+			} else {
+				buf = appendEscapedRune(buf, r)
+			}
+		default:
+			i += size
+			continue
+		}
+		i += size
+		start = i
+	}
+
+	buf = append(buf, s[start:]...)
+	buf = append(buf, mark)
+	return buf, nil
+}
+
+// appendLongEscapedRune appends \UXXXXXXXX for r.
+func appendLongEscapedRune(buf []byte, r rune) []byte {
+	const hex = "0123456789abcdef"
+	buf = append(buf, '\\', 'U')
+	for shift := 28; shift >= 0; shift -= 4 {
+		buf = append(buf, hex[r>>uint(shift)&0xf])
+	}
+	return buf
+}
+
+// appendEscapedRune appends \uXXXX for r in the BMP.
+func appendEscapedRune(buf []byte, r rune) []byte {
+	const hex = "0123456789abcdef"
+	return append(buf, '\\', 'u', hex[r>>12&0xf], hex[r>>8&0xf], hex[r>>4&0xf], hex[r&0xf])
+}
@@ -0,0 +1,86 @@
+package projson
+
+import (
+	"encoding/json"
+	"math/rand"
+	"testing"
+)
+
+func TestQuoteLikeMarshal(t *testing.T) {
+	strs := []string{"", "abc", `"\`, "<a href='x'>&amp;</a>", "\b\f\n\r\t\x00\x1f\x7f",
+		"日本語", "\U0001F600", "\u2028\u2029", "\xff", "a\xe2\x80b", "\xed\xa0\x80"}
+
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		b := make([]byte, rnd.Intn(16))
+		rnd.Read(b)
+		strs = append(strs, string(b))
+	}
+
+	jp := NewPrinter()
+	for _, s := range strs {
+		expected, _ := json.Marshal(s)
+		if actual, err := jp.quote(s); err != nil || string(expected) != actual {
+			t.Errorf("%q\nexpected: %v\nactual: %v (%v)", s, string(expected), actual, err)
+		}
+	}
+}
+
+func TestEscapeOptions(t *testing.T) {
+	const s = "<&> \u00e9\U0001F600\u2028\xff"
+	cases := []struct {
+		set      func(jp *JsonPrinter)
+		expected string
+	}{
+		{func(jp *JsonPrinter) {}, `["\u003c\u0026\u003e ` + "\u00e9\U0001F600" + `\u2028` + "\uFFFD" + `"]`},
+		{func(jp *JsonPrinter) { jp.SetEscapeHTML(false) }, `["<&> ` + "\u00e9\U0001F600" + `\u2028` + "\uFFFD" + `"]`},
+		{func(jp *JsonPrinter) { jp.SetASCIIOnly(true) }, `["\u003c\u0026\u003e \u00e9\ud83d\ude00\u2028\ufffd"]`},
+		{func(jp *JsonPrinter) { jp.SetEscapeHTML(false); jp.SetASCIIOnly(true) }, `["<&> \u00e9\ud83d\ude00\u2028\ufffd"]`},
+	}
+
+	for _, c := range cases {
+		jp := NewPrinter()
+		c.set(jp)
+		jp.Arr().Str(s).End()
+		if actual, err := jp.String(); err != nil || c.expected != actual {
+			t.Errorf("expected: %v\nactual: %v (%v)", c.expected, actual, err)
+		}
+	}
+
+	// keys are escaped the same way, in any style
+	jp := NewPrinter()
+	jp.SetStyle(PrettyStyle)
+	jp.SetEscapeHTML(false)
+	jp.SetASCIIOnly(true)
+	jp.Obj().Key("<\u00e9>").Int(1).End()
+
+	expected := `{"<\u00e9>": 1}`
+	if actual, _ := jp.String(); expected != actual {
+		t.Errorf("expected: %v\nactual: %v", expected, actual)
+	}
+}
+
+func TestStrictUTF8(t *testing.T) {
+	jp := NewPrinter()
+	jp.SetStrictUTF8(true)
+	if err := jp.Arr().Str("ok").Str("\xff").End().Error(); err == nil {
+		t.Errorf("invalid UTF-8 in a string must be an error")
+	}
+
+	jp = NewPrinter()
+	jp.SetStrictUTF8(true)
+	if err := jp.Obj().Key("a\xe2\x80").Int(1).End().Error(); err == nil {
+		t.Errorf("invalid UTF-8 in a key must be an error")
+	}
+}
+
+func TestQuoteAllocs(t *testing.T) {
+	jp := NewPrinter()
+	jp.quote("warm up the buffer <&> é")
+	allocs := testing.AllocsPerRun(100, func() {
+		jp.quote("tab\t<&> é")
+	})
+	if allocs > 1 {
+		t.Errorf("expected: at most 1 allocation\nactual: %v", allocs)
+	}
+}
@@ -0,0 +1,79 @@
+package projson
+
+import "testing"
+
+func TestBoolNull(t *testing.T) {
+	jp := NewPrinter()
+	jp.Arr().Bool(true).Bool(false).Null().Array([]interface{}{true, nil}).End()
+
+	expected := `[true,false,null,[true,null]]`
+	if actual, err := jp.String(); err != nil || expected != actual {
+		t.Errorf("expected: %v\nactual: %v (%v)", expected, actual, err)
+	}
+
+	jp = NewPrinter()
+	jp.SetColor(true)
+	jp.Obj().Key("b").Bool(true).Key("n").Null().End()
+
+	expected = "{" + color(`"b"`, colorKey) + ":" + color("true", colorBool) + "," +
+		color(`"n"`, colorKey) + ":" + color("null", colorNull) + "}"
+	if actual, _ := jp.String(); expected != actual {
+		t.Errorf("expected: %v\nactual: %v", expected, actual)
+	}
+}
+
+func TestHTMLColor(t *testing.T) {
+	jp := NewPrinter()
+	jp.SetColor(true)
+	jp.SetColorMode(HTMLColor)
+	jp.Obj().
+		Key("a").Arr().Int(1).FloatFmt(2, "%.1f<>").Str("<&>").Bool(true).Null().End().
+		End()
+
+	expected := `{<span class="json-key">"a"</span>:[<span class="json-number">1</span>,` +
+		`<span class="json-number">2.0&lt;&gt;</span>,<span class="json-string">"\u003c\u0026\u003e"</span>,` +
+		`<span class="json-bool">true</span>,<span class="json-null">null</span>]}`
+	if actual, _ := jp.String(); expected != actual {
+		t.Errorf("expected: %v\nactual: %v", expected, actual)
+	}
+
+	// without color, the output is plain JSON
+	jp = NewPrinter()
+	jp.SetColorMode(HTMLColor)
+	jp.Arr().Int(1).End()
+	if actual, _ := jp.String(); actual != "[1]" {
+		t.Errorf("expected: [1]\nactual: %v", actual)
+	}
+}
+
+func TestHTMLCollapsible(t *testing.T) {
+	cases := []struct {
+		style    int
+		expected string
+	}{
+		{PrettyStyle, `<details open><summary>{</summary>
+  <span class="json-key">"a"</span>: <details open><summary>[</summary>
+    <span class="json-number">1</span>,
+    <span class="json-number">2</span>
+  ]</details>,
+  <span class="json-key">"b"</span>: {}
+}</details>`},
+		{SmartStyle, `<details open><summary>{</summary><span class="json-key">"a"</span>: <details open><summary>[</summary><span class="json-number">1</span>,
+  <span class="json-number">2</span>]</details>,
+ <span class="json-key">"b"</span>: {}}</details>`},
+	}
+
+	for _, c := range cases {
+		jp := NewPrinter()
+		jp.SetStyle(c.style)
+		jp.SetTermWidth(10)
+		jp.SetColor(true)
+		jp.SetColorMode(HTMLColor)
+		jp.SetCollapsible(true)
+		jp.Obj().Key("a").Arr().Int(1).Int(2).End().Key("b").Obj().End().End()
+
+		if actual, _ := jp.String(); c.expected != actual {
+			t.Errorf("style %d\nexpected: %v\nactual: %v", c.style, c.expected, actual)
+		}
+	}
+}
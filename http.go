@@ -0,0 +1,255 @@
+package projson
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ResponsePrinter prints to an HTTP response in the form the request asks
+// for:
+//
+//   - NDJSON, one compact document per line, if the Accept header prefers
+//     application/x-ndjson to application/json;
+//   - SmartStyle JSON if the query has pretty, colored with ANSI escapes
+//     for pretty=color;
+//   - compact JSON otherwise.
+//
+// Each document is followed by a newline and flushed to the client once
+// complete, and compact documents are written out in chunks as they grow.
+// Putting more than one document is an error unless the response is
+// NDJSON. Close must be called at the end of the handler; calling it again
+// does nothing.
+type ResponsePrinter struct {
+	*JsonPrinter
+	w       http.ResponseWriter
+	ctype   string
+	ndjson  bool
+	written bool // the header has been written
+	closed  bool
+}
+
+// size of compact output written out before the document is complete
+const responseChunk = 4096
+
+func NewResponsePrinter(w http.ResponseWriter, r *http.Request) *ResponsePrinter {
+	rp := &ResponsePrinter{
+		JsonPrinter: AcquirePrinter(),
+		w:           w,
+		ctype:       "application/json",
+	}
+	rp.SetTermWidth(80)
+
+	accept := strings.Join(r.Header.Values("Accept"), ",")
+	pretty, hasPretty := r.URL.Query()["pretty"]
+
+	switch {
+	case acceptQuality(accept, "application/x-ndjson") > acceptQuality(accept, "application/json"):
+		rp.ndjson = true
+		rp.ctype = "application/x-ndjson"
+	case hasPretty && pretty[0] == "color":
+		rp.SetStyle(SmartStyle)
+		rp.SetColor(true)
+		rp.ctype = "text/plain; charset=utf-8"
+	case hasPretty:
+		if on, err := strconv.ParseBool(pretty[0]); on || err != nil {
+			rp.SetStyle(SmartStyle)
+		}
+	}
+
+	rp.SetEmitter(&responseEmitter{rp: rp})
+	return rp
+}
+
+// NDJSON reports whether documents are written as NDJSON.
+func (rp *ResponsePrinter) NDJSON() bool {
+	return rp.ndjson
+}
+
+// write writes out the output buffered so far.
+func (rp *ResponsePrinter) write() error {
+	if rp.buffer.Len() == 0 {
+		return nil
+	}
+
+	if !rp.written {
+		rp.w.Header().Set("Content-Type", rp.ctype)
+		rp.written = true
+	}
+
+	_, err := rp.w.Write(rp.buffer.Bytes())
+	rp.buffer.Reset()
+	if err != nil && rp.err == nil {
+		rp.err = err
+	}
+	return err
+}
+
+// Flush writes out the output so far and flushes it to the client.
+func (rp *ResponsePrinter) Flush() error {
+	if err := rp.write(); err != nil {
+		return err
+	}
+
+	if flusher, ok := rp.w.(http.Flusher); ok && rp.written {
+		flusher.Flush()
+	}
+	return nil
+}
+
+// Close completes the response and gives the printer back to the pool.
+// If printing failed or a document was left unfinished, the client gets
+// a 500 error if nothing has been written yet. Otherwise the response is
+// aborted by panicking with http.ErrAbortHandler, so that the client does
+// not take it as complete.
+func (rp *ResponsePrinter) Close() error {
+	if rp.closed {
+		return nil
+	}
+	rp.closed = true
+
+	err := rp.Error()
+	if err == nil && rp.state != stateInit && rp.state != stateFinal {
+		err = errors.New("Some object/array is not finished.")
+	}
+	if err == nil {
+		err = rp.Flush()
+	}
+	if err == nil && !rp.written {
+		rp.w.Header().Set("Content-Type", rp.ctype)
+	}
+
+	ReleasePrinter(rp.JsonPrinter)
+	rp.JsonPrinter = nil
+
+	if err != nil {
+		if rp.written {
+			panic(http.ErrAbortHandler)
+		}
+		http.Error(rp.w, err.Error(), http.StatusInternalServerError)
+	}
+	return err
+}
+
+// responseEmitter passes events on to the emitter of the style, and
+// writes out the output as documents complete.
+type responseEmitter struct {
+	rp    *ResponsePrinter
+	inner Emitter
+	depth int
+	docs  int // number of documents begun
+}
+
+func (e *responseEmitter) out() Emitter {
+	if e.inner == nil {
+		e.inner = e.rp.AsEmitter()
+	}
+	return e.inner
+}
+
+// begin checks that a value may be put, counting the documents begun.
+func (e *responseEmitter) begin() error {
+	if e.depth > 0 {
+		return nil
+	}
+	if e.docs > 0 && !e.rp.ndjson {
+		return errors.New("Response cannot have more than one document unless it is NDJSON")
+	}
+	e.docs++
+	return nil
+}
+
+func (e *responseEmitter) after(err error) error {
+	if err != nil {
+		return err
+	}
+
+	printer := e.rp.JsonPrinter
+	if e.depth == 0 {
+		printer.buffer.WriteByte('\n')
+		printer.linepos = 0
+		return e.rp.Flush()
+	}
+	if !printer.buffered() && printer.buffer.Len() >= responseChunk {
+		return e.rp.write()
+	}
+	return nil
+}
+
+func (e *responseEmitter) BeginArray(count int) error {
+	if err := e.begin(); err != nil {
+		return err
+	}
+	e.depth++
+	return e.after(e.out().BeginArray(count))
+}
+
+func (e *responseEmitter) EndArray() error {
+	e.depth--
+	return e.after(e.out().EndArray())
+}
+
+func (e *responseEmitter) BeginObject(count int) error {
+	if err := e.begin(); err != nil {
+		return err
+	}
+	e.depth++
+	return e.after(e.out().BeginObject(count))
+}
+
+func (e *responseEmitter) EndObject() error {
+	e.depth--
+	return e.after(e.out().EndObject())
+}
+
+func (e *responseEmitter) Key(key string) error {
+	return e.after(e.out().Key(key))
+}
+
+func (e *responseEmitter) Scalar(s Scalar) error {
+	if err := e.begin(); err != nil {
+		return err
+	}
+	return e.after(e.out().Scalar(s))
+}
+
+// acceptQuality returns the quality an Accept header gives to mediatype,
+// by its most specific matching media range.
+func acceptQuality(accept string, mediatype string) float64 {
+	major := mediatype[:strings.IndexByte(mediatype, '/')]
+
+	quality, specificity := 0.0, -1
+	for _, part := range strings.Split(accept, ",") {
+		typ, params, err := mime.ParseMediaType(part)
+		if err != nil {
+			continue
+		}
+
+		var s int
+		switch typ {
+		case mediatype:
+			s = 2
+		case major + "/*":
+			s = 1
+		case "*/*":
+			s = 0
+		default:
+			continue
+		}
+
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+
+		if s > specificity {
+			quality, specificity = q, s
+		}
+	}
+
+	return quality
+}
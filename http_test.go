@@ -0,0 +1,164 @@
+package projson
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// putRecords puts a document, and another one if the response is NDJSON.
+func putRecords(rp *ResponsePrinter) {
+	putValue(rp.JsonPrinter, map[string]interface{}{"name": "alice", "tags": []interface{}{"a", "b"}})
+	if rp.NDJSON() {
+		putValue(rp.JsonPrinter, []interface{}{1, 2})
+	}
+}
+
+func TestResponsePrinter(t *testing.T) {
+	cases := []struct {
+		accept string
+		query  string
+		ctype  string
+		body   string
+	}{
+		{"", "", "application/json", "{\"name\":\"alice\",\"tags\":[\"a\",\"b\"]}\n"},
+		{"*/*", "", "application/json", "{\"name\":\"alice\",\"tags\":[\"a\",\"b\"]}\n"},
+		{"application/x-ndjson", "", "application/x-ndjson", "{\"name\":\"alice\",\"tags\":[\"a\",\"b\"]}\n[1,2]\n"},
+		{"application/json, application/x-ndjson;q=0.5", "", "application/json", "{\"name\":\"alice\",\"tags\":[\"a\",\"b\"]}\n"},
+		{"application/x-ndjson", "?pretty", "application/x-ndjson", "{\"name\":\"alice\",\"tags\":[\"a\",\"b\"]}\n[1,2]\n"},
+		{"", "?pretty", "application/json", "{\"name\": \"alice\",\n \"tags\": [\"a\", \"b\"]}\n"},
+		{"", "?pretty=1", "application/json", "{\"name\": \"alice\",\n \"tags\": [\"a\", \"b\"]}\n"},
+		{"", "?pretty=false", "application/json", "{\"name\":\"alice\",\"tags\":[\"a\",\"b\"]}\n"},
+		{"", "?pretty=color", "text/plain; charset=utf-8",
+			"{" + color(`"name"`, colorKey) + ": " + color(`"alice"`, colorString) + ",\n " +
+				color(`"tags"`, colorKey) + ": [" + color(`"a"`, colorString) + ", " + color(`"b"`, colorString) + "]}\n"},
+	}
+
+	for _, c := range cases {
+		req := httptest.NewRequest("GET", "/"+c.query, nil)
+		if c.accept != "" {
+			req.Header.Set("Accept", c.accept)
+		}
+		rec := httptest.NewRecorder()
+
+		rp := NewResponsePrinter(rec, req)
+		putRecords(rp)
+		if err := rp.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		if ctype := rec.Header().Get("Content-Type"); ctype != c.ctype {
+			t.Errorf("%q %q\nexpected: %v\nactual: %v", c.accept, c.query, c.ctype, ctype)
+		}
+		if body := rec.Body.String(); body != c.body {
+			t.Errorf("%q %q\nexpected: %v\nactual: %v", c.accept, c.query, c.body, body)
+		}
+		if !rec.Flushed {
+			t.Errorf("%q %q: response is not flushed", c.accept, c.query)
+		}
+	}
+}
+
+func TestResponsePrinterStreaming(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	rec := httptest.NewRecorder()
+
+	rp := NewResponsePrinter(rec, req)
+	rp.BeginArray()
+	for i := 0; i < 10000; i++ {
+		rp.PutInt(i)
+	}
+	if rec.Body.Len() < 40000 {
+		t.Errorf("output is not written out before the document is complete")
+	}
+	rp.FinishArray()
+	rp.Close()
+
+	if body := rec.Body.String(); !strings.HasPrefix(body, "[0,1,2,") || !strings.HasSuffix(body, ",9999]\n") {
+		t.Errorf("broken output: %v...%v", body[:20], body[len(body)-20:])
+	}
+}
+
+func TestResponsePrinterError(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	rec := httptest.NewRecorder()
+
+	rp := NewResponsePrinter(rec, req)
+	rp.BeginObject()
+	rp.PutInt(1)
+	if err := rp.Close(); err == nil {
+		t.Errorf("expected: error\nactual: nil")
+	}
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("expected: %v\nactual: %v", http.StatusInternalServerError, rec.Code)
+	}
+
+	req = httptest.NewRequest("GET", "/", nil)
+	rec = httptest.NewRecorder()
+
+	rp = NewResponsePrinter(rec, req)
+	rp.BeginArray()
+	if err := rp.Close(); err == nil || rec.Code != http.StatusInternalServerError {
+		t.Errorf("unfinished document is not an error")
+	}
+	if err := rp.Close(); err != nil {
+		t.Errorf("expected: nil on second Close\nactual: %v", err)
+	}
+
+	req = httptest.NewRequest("GET", "/", nil)
+	rec = httptest.NewRecorder()
+
+	rp = NewResponsePrinter(rec, req)
+	rp.PutObject(map[string]interface{}{})
+	if err := rp.PutObject(map[string]interface{}{}); err == nil {
+		t.Errorf("second document of JSON is not an error")
+	}
+}
+
+func TestResponsePrinterAbort(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rp := NewResponsePrinter(w, r)
+		defer rp.Close()
+
+		rp.PutArray([]interface{}{1, 2})
+		rp.BeginArray()
+		rp.PutKey("not in an object")
+	}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err == nil {
+		t.Errorf("expected: error reading aborted response\nactual: %q", body)
+	}
+	if resp.StatusCode != http.StatusOK || string(body) != "[1,2]\n" {
+		t.Errorf("expected: 200 [1,2]\nactual: %v %q", resp.StatusCode, body)
+	}
+}
+
+func TestAcceptQuality(t *testing.T) {
+	cases := []struct {
+		accept string
+		q      float64
+	}{
+		{"", 0},
+		{"*/*", 1},
+		{"text/html", 0},
+		{"application/*;q=0.5", 0.5},
+		{"*/*;q=0.1, application/*;q=0.5, application/x-ndjson;q=0.8", 0.8},
+		{"application/x-ndjson;q=0, */*", 0},
+	}
+
+	for _, c := range cases {
+		if q := acceptQuality(c.accept, "application/x-ndjson"); q != c.q {
+			t.Errorf("%q\nexpected: %v\nactual: %v", c.accept, c.q, q)
+		}
+	}
+}
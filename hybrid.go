@@ -0,0 +1,156 @@
+package projson
+
+// HybridStyle is meant for numeric data. Objects are expanded one member
+// per line, arrays of scalars are packed into lines like SmartStyle, and
+// the rows of arrays of numeric arrays are put one per line with their
+// columns aligned:
+//
+//	{
+//	  "series": [
+//	    0.5, 1, 1.5, 2, 2.5, 3, 3.5, 4, 4.5, 5, 5.5, 6, 6.5, 7, 7.5, 8,
+//	    8.5, 9, 9.5
+//	  ],
+//	  "matrix": [
+//	    [  1, 0,   -1],
+//	    [100, 2, 3.25]
+//	  ]
+//	}
+//
+// Other arrays are laid out like PrettyStyle.
+
+func (printer *JsonPrinter) renderHybrid(n *node) {
+	printer.renderDoc(printer.hybridDoc(n, 0, 0), 0)
+}
+
+// hybridDoc returns the document for n at nesting depth, whose key is
+// padded to column.
+func (printer *JsonPrinter) hybridDoc(n *node, depth int, column int) *doc {
+	var value *doc
+	opener, closer := n.brackets()
+
+	var table *doc
+	if printer.table {
+		table = printer.tableDoc(n, depth)
+	}
+
+	switch {
+	case n.kind == nodeScalar:
+		value = textDoc(n.literal, n.colorliteral, printer.color)
+	case n.empty():
+		value = textDoc(opener+closer, opener+closer, printer.color)
+	case table != nil:
+		value = table
+	case n.hasObject():
+		// objects always break, and so does everything around them
+		members := []*doc{hardLineDoc()}
+		childColumn := printer.alignColumn(n)
+		for i, child := range n.children {
+			if i > 0 {
+				members = append(members, textDoc(",", ",", printer.color), hardLineDoc())
+			}
+			for _, text := range child.comments {
+				comment := lineComment(text)
+				members = append(members, textDoc(comment, printer.paint(comment, colorComment), printer.color), hardLineDoc())
+			}
+			members = append(members, printer.hybridDoc(child, depth+1, childColumn))
+		}
+		if printer.json5Option(JSON5TrailingCommas) {
+			members = append(members, textDoc(",", ",", printer.color))
+		}
+		for _, text := range n.trailing {
+			comment := lineComment(text)
+			members = append(members, hardLineDoc(), textDoc(comment, printer.paint(comment, colorComment), printer.color))
+		}
+		value = concatDoc(
+			textDoc(opener, printer.paintBracket(opener), printer.color),
+			nestDoc(prettyIndent, members...),
+			hardLineDoc(),
+			textDoc(closer, printer.paintBracket(closer), printer.color))
+	case n.isScalarArray():
+		parts := []*doc{}
+		for i, child := range n.children {
+			if i > 0 {
+				parts = append(parts, lineDoc())
+			}
+			if i < len(n.children)-1 {
+				parts = append(parts, textDoc(child.literal+",", child.colorliteral+",", printer.color))
+			} else {
+				parts = append(parts, textDoc(child.literal, child.colorliteral, printer.color))
+			}
+		}
+		value = groupDoc(
+			textDoc(opener, printer.paintBracket(opener), printer.color),
+			nestDoc(prettyIndent, softLineDoc(), fillDoc(parts...), concatDoc(printer.trailingDocs(n)...)),
+			softLineDoc(),
+			textDoc(closer, printer.paintBracket(closer), printer.color))
+	default:
+		var rows *doc
+		if n.isMatrix() {
+			rows = printer.matrixRows(n, depth)
+		}
+
+		if rows == nil {
+			members := []*doc{softLineDoc()}
+			for i, child := range n.children {
+				if i > 0 {
+					members = append(members, textDoc(",", ",", printer.color), lineDoc())
+				}
+				members = append(members, printer.leadingDocs(child)...)
+				members = append(members, printer.hybridDoc(child, depth+1, 0))
+			}
+			members = append(members, printer.trailingDocs(n)...)
+			rows = nestDoc(prettyIndent, members...)
+		}
+
+		value = groupDoc(
+			textDoc(opener, printer.paintBracket(opener), printer.color),
+			rows,
+			softLineDoc(),
+			textDoc(closer, printer.paintBracket(closer), printer.color))
+	}
+
+	if key := printer.keyDoc(n, column); key != nil {
+		return concatDoc(key, value)
+	}
+	return value
+}
+
+func (n *node) isScalarArray() bool {
+	if n.kind != nodeArray || n.commented() {
+		return false
+	}
+	for _, child := range n.children {
+		if child.kind != nodeScalar {
+			return false
+		}
+	}
+	return true
+}
+
+// isMatrix reports whether n is an array of non-empty arrays of numbers.
+func (n *node) isMatrix() bool {
+	if n.kind != nodeArray || n.commented() {
+		return false
+	}
+	for _, row := range n.children {
+		if row.kind != nodeArray || len(row.children) == 0 || row.commented() {
+			return false
+		}
+		for _, v := range row.children {
+			if !v.isNumber() {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// matrixRows returns the rows of the matrix n with aligned columns, or
+// nil if they do not fit in the terminal.
+func (printer *JsonPrinter) matrixRows(n *node, depth int) *doc {
+	rows := [][]*node{}
+	for _, row := range n.children {
+		rows = append(rows, row.children)
+	}
+	return printer.alignedRows(rows, "[", "]", depth)
+}
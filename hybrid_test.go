@@ -0,0 +1,135 @@
+package projson
+
+import "testing"
+
+const hybridTestDoc = `{"series": [0.5, 1, 1.5, 2, 2.5, 3, 3.5, 4, 4.5, 5, 5.5, 6, 6.5, 7, 7.5, 8, 8.5, 9, 9.5], "matrix": [[1, 0, -1], [100, 2, 3.25]], "short": [1, 2], "records": [{"a": 1}, {"b": [1, 2]}], "mixed": [[1, "a"], [2, "b"]], "empty": {}}`
+
+func TestHybridStyle(t *testing.T) {
+	cases := []struct {
+		width    int
+		expected string
+	}{
+		{80, `{
+  "series": [
+    0.5, 1, 1.5, 2, 2.5, 3, 3.5, 4, 4.5, 5, 5.5, 6, 6.5, 7, 7.5, 8, 8.5, 9, 9.5
+  ],
+  "matrix": [[1, 0, -1], [100, 2, 3.25]],
+  "short": [1, 2],
+  "records": [
+    {
+      "a": 1
+    },
+    {
+      "b": [1, 2]
+    }
+  ],
+  "mixed": [[1, "a"], [2, "b"]],
+  "empty": {}
+}`},
+		{20, `{
+  "series": [
+    0.5, 1, 1.5, 2,
+    2.5, 3, 3.5, 4,
+    4.5, 5, 5.5, 6,
+    6.5, 7, 7.5, 8,
+    8.5, 9, 9.5
+  ],
+  "matrix": [
+    [  1, 0,   -1],
+    [100, 2, 3.25]
+  ],
+  "short": [1, 2],
+  "records": [
+    {
+      "a": 1
+    },
+    {
+      "b": [1, 2]
+    }
+  ],
+  "mixed": [
+    [1, "a"],
+    [2, "b"]
+  ],
+  "empty": {}
+}`},
+		// aligned rows do not fit; pack each row instead
+		{14, `{
+  "series": [
+    0.5, 1,
+    1.5, 2,
+    2.5, 3,
+    3.5, 4,
+    4.5, 5,
+    5.5, 6,
+    6.5, 7,
+    7.5, 8,
+    8.5, 9,
+    9.5
+  ],
+  "matrix": [
+    [
+      1, 0, -1
+    ],
+    [
+      100, 2,
+      3.25
+    ]
+  ],
+  "short": [
+    1, 2
+  ],
+  "records": [
+    {
+      "a": 1
+    },
+    {
+      "b": [
+        1, 2
+      ]
+    }
+  ],
+  "mixed": [
+    [1, "a"],
+    [2, "b"]
+  ],
+  "empty": {}
+}`},
+	}
+
+	for _, c := range cases {
+		jp := NewPrinter()
+		jp.SetStyle(HybridStyle)
+		jp.SetTermWidth(c.width)
+		if err := putJSON(jp, hybridTestDoc); err != nil {
+			t.Fatal(err)
+		}
+
+		actual, _ := jp.String()
+		if c.expected != actual {
+			t.Errorf("width %d\nexpected: %v\nactual: %v", c.width, c.expected, actual)
+		}
+	}
+}
+
+func TestHybridStyleColor(t *testing.T) {
+	jp := NewPrinter()
+	jp.SetStyle(HybridStyle)
+	jp.SetTermWidth(14)
+	jp.SetColor(true)
+
+	jp.BeginArray()
+	jp.PutArray([]interface{}{1, 2.5})
+	jp.PutArray([]interface{}{10, 0})
+	jp.FinishArray()
+
+	expected := "[\n  [ " + color("1", colorInt) + ", " + color("2.5", colorFloat) + "],\n" +
+		"  [" + color("10", colorInt) + ",   " + color("0", colorInt) + "]\n]"
+	if actual, _ := jp.String(); expected != actual {
+		t.Errorf("expected: %v\nactual: %v", expected, actual)
+	}
+}
+
+func TestHybridLineWidthProperty(t *testing.T) {
+	checkLineWidths(t, HybridStyle, 13)
+}
@@ -0,0 +1,256 @@
+package projson
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// JSONCFormat is JSON with comments, and JSON5Format adds the JSON5
+// features turned on with SetJSON5Options. Comments put with PutComment
+// are written as line comments where the layout breaks lines, and as
+// block comments elsewhere:
+//
+//	{
+//	  // the name
+//	  name: 'alice',
+//	  tags: ['a' /* first */, 'b'],
+//	}
+
+// options of SetJSON5Options
+const (
+	JSON5UnquotedKeys   = 1 << iota // keys that are identifiers are not quoted
+	JSON5TrailingCommas             // a comma follows the last member of broken containers
+	JSON5SingleQuotes               // strings are put in single quotes
+	JSON5HexNumbers                 // integers are put in hexadecimal
+)
+
+// Commenter is implemented by emitters taking the comments put to the
+// printer.
+type Commenter interface {
+	Comment(text string) error
+}
+
+func (printer *JsonPrinter) SetJSON5Options(options int) error {
+	if printer.err != nil {
+		return printer.err
+	}
+
+	if printer.state != stateInit {
+		printer.err = errors.New("JSON5 options cannot changed after putting some items")
+		return printer.err
+	}
+
+	printer.json5 = options
+	return nil
+}
+
+// SetRejectComments makes putting comments an error in formats that
+// cannot hold them, instead of dropping them.
+func (printer *JsonPrinter) SetRejectComments(reject bool) error {
+	if printer.err != nil {
+		return printer.err
+	}
+
+	if printer.state != stateInit {
+		printer.err = errors.New("Comment rejection cannot changed after putting some items")
+		return printer.err
+	}
+
+	printer.nocomments = reject
+	return nil
+}
+
+// PutComment puts a comment before what is put next, or after the last
+// member of the current array or object if it is finished next.
+func (printer *JsonPrinter) PutComment(text string) error {
+	if printer.err != nil {
+		return printer.err
+	}
+
+	if strings.Contains(text, "*/") {
+		printer.err = errors.New("Comment cannot contain */")
+		return printer.err
+	}
+
+	commenter, ok := printer.target().(Commenter)
+	if !ok || (printer.emitter == nil && !printer.commentable()) {
+		if printer.nocomments {
+			printer.err = errors.New("Comments cannot be represented in this format")
+			return printer.err
+		}
+		return nil
+	}
+
+	if err := commenter.Comment(text); err != nil {
+		printer.err = err
+		return printer.err
+	}
+
+	return nil
+}
+
+// commentable reports whether the format holds comments.
+func (printer *JsonPrinter) commentable() bool {
+	return printer.format == JSONCFormat || printer.format == JSON5Format
+}
+
+func (printer *JsonPrinter) json5Option(option int) bool {
+	return printer.format == JSON5Format && printer.json5&option != 0
+}
+
+// quoteMark returns the quotation mark of strings and keys.
+func (printer *JsonPrinter) quoteMark() byte {
+	if printer.json5Option(JSON5SingleQuotes) {
+		return '\''
+	}
+	return '"'
+}
+
+// quoteKey returns key as it is put, unquoted if it can be.
+func (printer *JsonPrinter) quoteKey(key string) (string, error) {
+	if printer.json5Option(JSON5UnquotedKeys) && isIdentifier(key) {
+		return key, nil
+	}
+	return printer.quote(key)
+}
+
+// isIdentifier reports whether s is an ECMAScript identifier name, which
+// JSON5 takes as a key as it is.
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		switch {
+		case r == '$' || r == '_' || unicode.IsLetter(r):
+		case i > 0 && (unicode.IsDigit(r) || unicode.In(r, unicode.Mn, unicode.Mc, unicode.Pc)):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// formatInt returns the literal of v, in hexadecimal if so set.
+func (printer *JsonPrinter) formatInt(v int64) string {
+	if !printer.json5Option(JSON5HexNumbers) {
+		return strconv.FormatInt(v, 10)
+	}
+
+	if v < 0 {
+		return "-0x" + strconv.FormatUint(uint64(-v), 16)
+	}
+	return "0x" + strconv.FormatUint(uint64(v), 16)
+}
+
+func (printer *JsonPrinter) formatUint(v uint64) string {
+	if !printer.json5Option(JSON5HexNumbers) {
+		return strconv.FormatUint(v, 10)
+	}
+	return "0x" + strconv.FormatUint(v, 16)
+}
+
+func lineComment(text string) string {
+	if strings.ContainsAny(text, "\r\n") {
+		return blockComment(text)
+	}
+	return "// " + text
+}
+
+func blockComment(text string) string {
+	return "/* " + text + " */"
+}
+
+// commentDoc returns a comment, as a line comment if the enclosing group
+// breaks, so it must be followed by a line.
+func (printer *JsonPrinter) commentDoc(text string) *doc {
+	line, block := lineComment(text), blockComment(text)
+	return ifBreakDoc(
+		textDoc(line, printer.paint(line, colorComment), printer.color),
+		textDoc(block, printer.paint(block, colorComment), printer.color))
+}
+
+// leadingDocs returns the comments put before n, each followed by a line.
+func (printer *JsonPrinter) leadingDocs(n *node) []*doc {
+	docs := []*doc{}
+	for _, text := range n.comments {
+		docs = append(docs, printer.commentDoc(text), lineDoc())
+	}
+	return docs
+}
+
+// trailingDocs returns what follows the last member of the container n:
+// a trailing comma and the comments put after the member.
+func (printer *JsonPrinter) trailingDocs(n *node) []*doc {
+	docs := []*doc{}
+	if len(n.children) > 0 && printer.json5Option(JSON5TrailingCommas) {
+		docs = append(docs, ifBreakDoc(textDoc(",", ",", printer.color), textDoc("", "", false)))
+	}
+	for i, text := range n.trailing {
+		if i > 0 || len(n.children) > 0 {
+			docs = append(docs, lineDoc())
+		}
+		docs = append(docs, printer.commentDoc(text))
+	}
+	return docs
+}
+
+// commented reports whether comments are put inside n, so that it cannot
+// be laid out as a table or a matrix.
+func (n *node) commented() bool {
+	if len(n.trailing) > 0 {
+		return true
+	}
+	for _, child := range n.children {
+		if len(child.comments) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *simpleEmitter) Comment(text string) error {
+	if !e.printer.commentable() {
+		return nil
+	}
+
+	if len(e.members) == 0 {
+		e.write(blockComment(text), colorComment)
+		return nil
+	}
+	e.comments = append(e.comments, text)
+	return nil
+}
+
+func (e *treeEmitter) Comment(text string) error {
+	if !e.printer.commentable() {
+		return nil
+	}
+
+	if len(e.stack) == 0 {
+		// between documents, on a line of its own
+		if e.printer.linepos > 0 {
+			e.printer.buffer.WriteString("\n")
+		}
+		comment := lineComment(text)
+		e.printer.buffer.WriteString(e.printer.colorize(comment, colorComment) + "\n")
+		e.printer.linepos = 0
+		return nil
+	}
+
+	e.comments = append(e.comments, text)
+	return nil
+}
+
+func (tee teeEmitter) Comment(text string) error {
+	for _, emitter := range tee {
+		if commenter, ok := emitter.(Commenter); ok {
+			if err := commenter.Comment(text); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package projson
+
+import (
+	"math"
+	"testing"
+)
+
+func putCommented(jp *JsonPrinter) {
+	jp.PutComment("config")
+	jp.BeginObject()
+	jp.PutComment("the name")
+	jp.PutKey("name")
+	jp.PutString("it's \"a\"")
+	jp.PutKey("my-key")
+	jp.PutInt(255)
+	jp.PutKey("tags")
+	jp.BeginArray()
+	jp.PutInt(-16)
+	jp.PutComment("last")
+	jp.FinishArray()
+	jp.PutKey("o")
+	jp.BeginObject()
+	jp.PutComment("empty")
+	jp.FinishObject()
+	jp.FinishObject()
+}
+
+func TestJSON5(t *testing.T) {
+	cases := []struct {
+		style    int
+		termwid  int
+		expected string
+	}{
+		{SimpleStyle, 80, `/* config */{/* the name */name:'it\'s "a"','my-key':0xff,tags:[-0x10/* last */],o:{/* empty */}}`},
+		{SmartStyle, 20, `// config
+{/* the name */ name: 'it\'s "a"',
+ 'my-key': 0xff,
+ tags: [-0x10
+  /* last */],
+ o: {/* empty */}}`},
+		{PrettyStyle, 80, `// config
+{
+  // the name
+  name: 'it\'s "a"',
+  'my-key': 0xff,
+  tags: [-0x10 /* last */],
+  o: {/* empty */},
+}`},
+		{HybridStyle, 20, `// config
+{
+  // the name
+  name: 'it\'s "a"',
+  'my-key': 0xff,
+  tags: [
+    -0x10,
+    // last
+  ],
+  o: {/* empty */},
+}`},
+	}
+
+	for _, c := range cases {
+		jp := NewPrinter()
+		jp.SetFormat(JSON5Format)
+		jp.SetJSON5Options(JSON5UnquotedKeys | JSON5TrailingCommas | JSON5SingleQuotes | JSON5HexNumbers)
+		jp.SetStyle(c.style)
+		jp.SetTermWidth(c.termwid)
+		putCommented(jp)
+
+		if actual, err := jp.String(); err != nil || c.expected != actual {
+			t.Errorf("style %d\nexpected: %v\nactual: %v (%v)", c.style, c.expected, actual, err)
+		}
+	}
+}
+
+func TestJSONC(t *testing.T) {
+	jp := NewPrinter()
+	jp.SetFormat(JSONCFormat)
+	jp.SetJSON5Options(JSON5UnquotedKeys | JSON5HexNumbers)
+	jp.SetStyle(PrettyStyle)
+	jp.SetTermWidth(20)
+	putCommented(jp)
+
+	expected := `// config
+{
+  // the name
+  "name": "it's \"a\"",
+  "my-key": 255,
+  "tags": [
+    -16
+    // last
+  ],
+  "o": {/* empty */}
+}`
+	if actual, err := jp.String(); err != nil || expected != actual {
+		t.Errorf("expected: %v\nactual: %v (%v)", expected, actual, err)
+	}
+}
+
+func TestCommentsInJSON(t *testing.T) {
+	jp := NewPrinter()
+	jp.SetJSON5Options(JSON5UnquotedKeys | JSON5SingleQuotes)
+	putCommented(jp)
+
+	expected := `{"name":"it's \"a\"","my-key":255,"tags":[-16],"o":{}}`
+	if actual, err := jp.String(); err != nil || expected != actual {
+		t.Errorf("expected: %v\nactual: %v (%v)", expected, actual, err)
+	}
+
+	jp = NewPrinter()
+	jp.SetRejectComments(true)
+	jp.BeginArray()
+	if err := jp.PutComment("c"); err == nil {
+		t.Errorf("expected: error\nactual: nil")
+	}
+
+	jp = NewPrinter()
+	jp.SetFormat(JSONCFormat)
+	if err := jp.PutComment("a */ b"); err == nil {
+		t.Errorf("expected: error\nactual: nil")
+	}
+}
+
+func TestReplayComments(t *testing.T) {
+	recorder := NewRecorder()
+	jp := NewPrinter()
+	jp.SetRejectComments(true)
+	jp.SetEmitter(recorder)
+	jp.BeginArray()
+	jp.PutInt(1)
+	if err := jp.PutComment("one"); err != nil {
+		t.Fatal(err)
+	}
+	jp.FinishArray()
+
+	jp = NewPrinter()
+	jp.SetFormat(JSONCFormat)
+	recorder.Replay(jp)
+
+	expected := "[1/* one */]"
+	if actual, err := jp.String(); err != nil || expected != actual {
+		t.Errorf("expected: %v\nactual: %v (%v)", expected, actual, err)
+	}
+}
+
+func TestSimpleComments(t *testing.T) {
+	// comments go after the separator, as in the other styles
+	jp := NewPrinter()
+	jp.SetFormat(JSONCFormat)
+	jp.BeginArray()
+	jp.PutInt(1)
+	jp.PutComment("c")
+	jp.PutInt(2)
+	jp.BeginObject()
+	jp.PutKey("a")
+	jp.PutComment("d")
+	jp.PutString("x")
+	jp.PutComment("e")
+	jp.PutKey("b")
+	jp.PutBool(true)
+	jp.FinishObject()
+	jp.FinishArray()
+
+	expected := `[1,/* c */2,{"a":/* d */"x",/* e */"b":true}]`
+	if actual, err := jp.String(); err != nil || expected != actual {
+		t.Errorf("expected: %v\nactual: %v (%v)", expected, actual, err)
+	}
+}
+
+func TestJSON5Literals(t *testing.T) {
+	jp := NewPrinter()
+	jp.SetFormat(JSON5Format)
+	jp.SetJSON5Options(JSON5HexNumbers)
+	if actual := jp.formatInt(math.MinInt64); actual != "-0x8000000000000000" {
+		t.Errorf("expected: -0x8000000000000000\nactual: %v", actual)
+	}
+
+	cases := []struct {
+		s          string
+		identifier bool
+	}{
+		{"a", true},
+		{"_a1", true},
+		{"$", true},
+		{"日本", true},
+		{"", false},
+		{"1a", false},
+		{"a-b", false},
+		{"a b", false},
+	}
+
+	for _, c := range cases {
+		if actual := isIdentifier(c.s); actual != c.identifier {
+			t.Errorf("%q\nexpected: %v\nactual: %v", c.s, c.identifier, actual)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package projson
+
+// A small document algebra in the style of Wadler's "prettier printer"
+// and Oppen's pretty printer. A document is text with possible line
+// breaks; a group is laid out on one line if it fits within termwid,
+// otherwise each line in it (but not in nested groups) is broken.
+
+type docKind int
+
+const (
+	docText     docKind = iota
+	docLine             // a space, or a newline if the enclosing group breaks
+	docSoftLine         // nothing, or a newline if the enclosing group breaks
+	docHardLine         // always a newline
+	docConcat
+	docNest // increases indentation of line breaks in its content
+	docGroup
+	docFill    // contents and lines alternately; a line breaks only if the next content does not fit
+	docIfBreak // children[0] if the enclosing group breaks, otherwise children[1]
+)
+
+type doc struct {
+	kind     docKind
+	text     string // what is written, possibly with color escapes
+	width    int    // display width of text
+	indent   int
+	children []*doc
+}
+
+func textDoc(text string, colortext string, colored bool) *doc {
+	d := &doc{kind: docText, text: text, width: displayWidth(text)}
+	if colored {
+		d.text = colortext
+	}
+	return d
+}
+
+func lineDoc() *doc {
+	return &doc{kind: docLine}
+}
+
+func softLineDoc() *doc {
+	return &doc{kind: docSoftLine}
+}
+
+func hardLineDoc() *doc {
+	return &doc{kind: docHardLine}
+}
+
+func concatDoc(children ...*doc) *doc {
+	return &doc{kind: docConcat, children: children}
+}
+
+func nestDoc(indent int, children ...*doc) *doc {
+	return &doc{kind: docNest, indent: indent, children: children}
+}
+
+func groupDoc(children ...*doc) *doc {
+	return &doc{kind: docGroup, children: children}
+}
+
+func fillDoc(children ...*doc) *doc {
+	return &doc{kind: docFill, children: children}
+}
+
+func ifBreakDoc(broken *doc, flat *doc) *doc {
+	return &doc{kind: docIfBreak, children: []*doc{broken, flat}}
+}
+
+type layoutCmd struct {
+	indent int
+	flat   bool
+	d      *doc
+}
+
+// pushChildren pushes the children of c.d so that the first one is on
+// top of the stack.
+func pushChildren(cmds []layoutCmd, c layoutCmd) []layoutCmd {
+	for i := len(c.d.children) - 1; i >= 0; i-- {
+		cmds = append(cmds, layoutCmd{c.indent, c.flat, c.d.children[i]})
+	}
+	return cmds
+}
+
+// fits reports whether next, in flat mode, and then the remaining
+// commands up to their first line break fit in width cells.
+func fits(next layoutCmd, rest []layoutCmd, width int) bool {
+	cmds := []layoutCmd{next}
+	restIdx := len(rest)
+
+	for width >= 0 {
+		if len(cmds) == 0 {
+			if restIdx == 0 {
+				return true
+			}
+			restIdx--
+			cmds = append(cmds, rest[restIdx])
+		}
+
+		c := cmds[len(cmds)-1]
+		cmds = cmds[:len(cmds)-1]
+
+		switch c.d.kind {
+		case docText:
+			width -= c.d.width
+		case docLine, docSoftLine:
+			if !c.flat {
+				return true
+			}
+			if c.d.kind == docLine {
+				width--
+			}
+		case docHardLine:
+			return true
+		case docNest:
+			cmds = pushChildren(cmds, layoutCmd{c.indent + c.d.indent, c.flat, c.d})
+		case docIfBreak:
+			cmds = append(cmds, c.branch())
+		case docFill:
+			if !c.flat {
+				// only the first content is measured
+				cmds = append(cmds, layoutCmd{c.indent, true, c.d.children[0]})
+				break
+			}
+			cmds = pushChildren(cmds, c)
+		default:
+			cmds = pushChildren(cmds, c)
+		}
+	}
+
+	return false
+}
+
+// renderDoc writes d into the buffer, starting at the current line
+// position. Line breaks are indented by base plus the nesting.
+func (printer *JsonPrinter) renderDoc(d *doc, base int) {
+	cmds := []layoutCmd{{base, false, d}}
+
+	for len(cmds) > 0 {
+		c := cmds[len(cmds)-1]
+		cmds = cmds[:len(cmds)-1]
+
+		switch c.d.kind {
+		case docText:
+			printer.buffer.WriteString(c.d.text)
+			printer.linepos += c.d.width
+		case docLine, docSoftLine, docHardLine:
+			if !c.flat || c.d.kind == docHardLine {
+				printer.buffer.WriteString("\n")
+				printer.buffer.WriteString(indent(" ", c.indent))
+				printer.linepos = c.indent
+			} else if c.d.kind == docLine {
+				printer.buffer.WriteString(" ")
+				printer.linepos++
+			}
+		case docConcat:
+			cmds = pushChildren(cmds, c)
+		case docNest:
+			cmds = pushChildren(cmds, layoutCmd{c.indent + c.d.indent, c.flat, c.d})
+		case docGroup:
+			flat := layoutCmd{c.indent, true, c.d}
+			if c.flat || fits(flat, cmds, printer.termwid-printer.linepos) {
+				cmds = pushChildren(cmds, flat)
+			} else {
+				cmds = pushChildren(cmds, c)
+			}
+		case docIfBreak:
+			cmds = append(cmds, c.branch())
+		case docFill:
+			cmds = printer.layoutFill(cmds, c)
+		}
+	}
+}
+
+func (c layoutCmd) branch() layoutCmd {
+	if c.flat {
+		return layoutCmd{c.indent, c.flat, c.d.children[1]}
+	}
+	return layoutCmd{c.indent, c.flat, c.d.children[0]}
+}
+
+// layoutFill lays out the first content of a fill and the line after it,
+// and pushes the rest of the fill back. Contents are always flat.
+func (printer *JsonPrinter) layoutFill(cmds []layoutCmd, c layoutCmd) []layoutCmd {
+	parts := c.d.children
+	if len(parts) == 0 {
+		return cmds
+	}
+
+	rem := printer.termwid - printer.linepos
+	content := layoutCmd{c.indent, true, parts[0]}
+	if len(parts) == 1 || c.flat {
+		if len(parts) > 1 {
+			cmds = append(cmds, layoutCmd{c.indent, true, fillDoc(parts[1:]...)})
+		}
+		return append(cmds, content)
+	}
+
+	// break the line unless the next content fits after it
+	next := layoutCmd{c.indent, true, concatDoc(parts[0], parts[1], parts[2])}
+	line := layoutCmd{c.indent, fits(next, nil, rem), parts[1]}
+
+	cmds = append(cmds, layoutCmd{c.indent, false, fillDoc(parts[2:]...)})
+	return append(cmds, line, content)
+}
@@ -0,0 +1,162 @@
+package projson
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"reflect"
+)
+
+// MessagePackFormat puts values as MessagePack objects, one after another.
+// MessagePack has no indefinite-length containers, so arrays and objects
+// are buffered until they are finished. Floats are written in single
+// precision if it keeps their value, and formats given to PutFloatFmt
+// are not applied. PutBytes writes bin objects.
+
+func (printer *JsonPrinter) renderMessagePack(n *node) error {
+	switch n.kind {
+	case nodeArray:
+		printer.writeMsgpackHead(0x90, 0xdc, len(n.children))
+		for _, child := range n.children {
+			if err := printer.renderMessagePack(child); err != nil {
+				return err
+			}
+		}
+	case nodeObject:
+		printer.writeMsgpackHead(0x80, 0xde, len(n.children))
+		for _, child := range n.children {
+			printer.writeMsgpackValue(child.name)
+			if err := printer.renderMessagePack(child); err != nil {
+				return err
+			}
+		}
+	default:
+		if n.bytes != nil {
+			printer.writeMsgpackBin(n.bytes)
+			return nil
+		}
+		return printer.writeMsgpackValue(n.value)
+	}
+	return nil
+}
+
+// writeMsgpackHead writes the head of an array or a map of n members: fix
+// is the fixarray or fixmap prefix, and wide the 16-bit variant, which is
+// followed by the 32-bit one.
+func (printer *JsonPrinter) writeMsgpackHead(fix byte, wide byte, n int) {
+	var buf [5]byte
+	switch {
+	case n < 16:
+		printer.buffer.WriteByte(fix | byte(n))
+	case n <= math.MaxUint16:
+		buf[0] = wide
+		binary.BigEndian.PutUint16(buf[1:], uint16(n))
+		printer.buffer.Write(buf[:3])
+	default:
+		buf[0] = wide + 1
+		binary.BigEndian.PutUint32(buf[1:], uint32(n))
+		printer.buffer.Write(buf[:5])
+	}
+}
+
+func (printer *JsonPrinter) writeMsgpackValue(value interface{}) error {
+	var buf [9]byte
+	switch v := value.(type) {
+	case int64:
+		switch {
+		case v >= 0 && v <= math.MaxInt8, v < 0 && v >= -32:
+			printer.buffer.WriteByte(byte(v))
+		case v >= 0 && v <= math.MaxUint8:
+			buf[0], buf[1] = 0xcc, byte(v)
+			printer.buffer.Write(buf[:2])
+		case v >= 0 && v <= math.MaxUint16:
+			buf[0] = 0xcd
+			binary.BigEndian.PutUint16(buf[1:], uint16(v))
+			printer.buffer.Write(buf[:3])
+		case v >= 0 && v <= math.MaxUint32:
+			buf[0] = 0xce
+			binary.BigEndian.PutUint32(buf[1:], uint32(v))
+			printer.buffer.Write(buf[:5])
+		case v >= 0:
+			buf[0] = 0xcf
+			binary.BigEndian.PutUint64(buf[1:], uint64(v))
+			printer.buffer.Write(buf[:9])
+		case v >= math.MinInt8:
+			buf[0], buf[1] = 0xd0, byte(v)
+			printer.buffer.Write(buf[:2])
+		case v >= math.MinInt16:
+			buf[0] = 0xd1
+			binary.BigEndian.PutUint16(buf[1:], uint16(v))
+			printer.buffer.Write(buf[:3])
+		case v >= math.MinInt32:
+			buf[0] = 0xd2
+			binary.BigEndian.PutUint32(buf[1:], uint32(v))
+			printer.buffer.Write(buf[:5])
+		default:
+			buf[0] = 0xd3
+			binary.BigEndian.PutUint64(buf[1:], uint64(v))
+			printer.buffer.Write(buf[:9])
+		}
+	case uint64:
+		buf[0] = 0xcf
+		binary.BigEndian.PutUint64(buf[1:], v)
+		printer.buffer.Write(buf[:9])
+	case float64:
+		if float64(float32(v)) == v || math.IsNaN(v) {
+			buf[0] = 0xca
+			binary.BigEndian.PutUint32(buf[1:], math.Float32bits(float32(v)))
+			printer.buffer.Write(buf[:5])
+		} else {
+			buf[0] = 0xcb
+			binary.BigEndian.PutUint64(buf[1:], math.Float64bits(v))
+			printer.buffer.Write(buf[:9])
+		}
+	case string:
+		switch n := len(v); {
+		case n < 32:
+			printer.buffer.WriteByte(0xa0 | byte(n))
+		case n <= math.MaxUint8:
+			buf[0], buf[1] = 0xd9, byte(n)
+			printer.buffer.Write(buf[:2])
+		case n <= math.MaxUint16:
+			buf[0] = 0xda
+			binary.BigEndian.PutUint16(buf[1:], uint16(n))
+			printer.buffer.Write(buf[:3])
+		default:
+			buf[0] = 0xdb
+			binary.BigEndian.PutUint32(buf[1:], uint32(n))
+			printer.buffer.Write(buf[:5])
+		}
+		printer.buffer.WriteString(v)
+	case bool:
+		if v {
+			printer.buffer.WriteByte(0xc3)
+		} else {
+			printer.buffer.WriteByte(0xc2)
+		}
+	case nil:
+		printer.buffer.WriteByte(0xc0)
+	default:
+		return errors.New("Cannot put value of type " + reflect.TypeOf(value).String() + " in MessagePack")
+	}
+	return nil
+}
+
+// writeMsgpackBin writes b as a bin object.
+func (printer *JsonPrinter) writeMsgpackBin(b []byte) {
+	var buf [5]byte
+	switch n := len(b); {
+	case n <= math.MaxUint8:
+		buf[0], buf[1] = 0xc4, byte(n)
+		printer.buffer.Write(buf[:2])
+	case n <= math.MaxUint16:
+		buf[0] = 0xc5
+		binary.BigEndian.PutUint16(buf[1:], uint16(n))
+		printer.buffer.Write(buf[:3])
+	default:
+		buf[0] = 0xc6
+		binary.BigEndian.PutUint32(buf[1:], uint32(n))
+		printer.buffer.Write(buf[:5])
+	}
+	printer.buffer.Write(b)
+}
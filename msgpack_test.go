@@ -0,0 +1,191 @@
+package projson
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math"
+	"strconv"
+	"testing"
+)
+
+// decodeMsgpack decodes the first object in data into compact JSON,
+// independently of the encoder, and returns the rest of data.
+func decodeMsgpack(data []byte, out *bytes.Buffer) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, errors.New("unexpected end of data")
+	}
+	b := data[0]
+	data = data[1:]
+
+	// big-endian argument of size bytes
+	arg := func(size int) (uint64, error) {
+		if len(data) < size {
+			return 0, errors.New("truncated data")
+		}
+		var v uint64
+		for _, b := range data[:size] {
+			v = v<<8 | uint64(b)
+		}
+		data = data[size:]
+		return v, nil
+	}
+
+	var n uint64
+	var err error
+	switch {
+	case b <= 0x7f:
+		out.WriteString(strconv.Itoa(int(b)))
+		return data, nil
+	case b >= 0xe0:
+		out.WriteString(strconv.Itoa(int(int8(b))))
+		return data, nil
+	case b >= 0xc0 && b <= 0xc3 && b != 0xc1:
+		out.WriteString(map[byte]string{0xc0: "null", 0xc2: "false", 0xc3: "true"}[b])
+		return data, nil
+	case b >= 0xcc && b <= 0xcf:
+		if n, err = arg(1 << (b - 0xcc)); err != nil {
+			return nil, err
+		}
+		out.WriteString(strconv.FormatUint(n, 10))
+		return data, nil
+	case b >= 0xd0 && b <= 0xd3:
+		size := 1 << (b - 0xd0)
+		if n, err = arg(size); err != nil {
+			return nil, err
+		}
+		// sign-extend
+		shift := uint(64 - 8*size)
+		out.WriteString(strconv.FormatInt(int64(n<<shift)>>shift, 10))
+		return data, nil
+	case b == 0xca || b == 0xcb:
+		var f float64
+		if b == 0xca {
+			n, err = arg(4)
+			f = float64(math.Float32frombits(uint32(n)))
+		} else {
+			n, err = arg(8)
+			f = math.Float64frombits(n)
+		}
+		if err != nil {
+			return nil, err
+		}
+		out.WriteString(strconv.FormatFloat(f, 'f', -1, 64))
+		return data, nil
+	case b&0xe0 == 0xa0, b >= 0xd9 && b <= 0xdb:
+		if b&0xe0 == 0xa0 {
+			n = uint64(b & 0x1f)
+		} else if n, err = arg(1 << (b - 0xd9)); err != nil {
+			return nil, err
+		}
+		if uint64(len(data)) < n {
+			return nil, errors.New("truncated string")
+		}
+		s, _ := json.Marshal(string(data[:n]))
+		out.Write(s)
+		return data[n:], nil
+	}
+
+	keyed := false
+	switch {
+	case b&0xf0 == 0x90:
+		n = uint64(b & 0x0f)
+	case b&0xf0 == 0x80:
+		n, keyed = uint64(b&0x0f), true
+	case b == 0xdc || b == 0xdd:
+		n, err = arg(2 << (b - 0xdc))
+	case b == 0xde || b == 0xdf:
+		n, err = arg(2 << (b - 0xde))
+		keyed = true
+	default:
+		return nil, errors.New("unsupported type")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	opener, closer := "[", "]"
+	if keyed {
+		opener, closer = "{", "}"
+	}
+	out.WriteString(opener)
+	for i := uint64(0); i < n; i++ {
+		if i > 0 {
+			out.WriteByte(',')
+		}
+		if keyed {
+			if data, err = decodeMsgpack(data, out); err != nil {
+				return nil, err
+			}
+			out.WriteByte(':')
+		}
+		if data, err = decodeMsgpack(data, out); err != nil {
+			return nil, err
+		}
+	}
+	out.WriteString(closer)
+	return data, nil
+}
+
+func TestMessagePackRoundTrip(t *testing.T) {
+	checkBinaryRoundTrip(t, MessagePackFormat, decodeMsgpack)
+}
+
+func TestMessagePackVectors(t *testing.T) {
+	long := string(bytes.Repeat([]byte("x"), 32))
+	many := make([]interface{}, 16)
+	for i := range many {
+		many[i] = i
+	}
+
+	cases := []struct {
+		put      func(jp *JsonPrinter)
+		expected string
+	}{
+		{func(jp *JsonPrinter) { jp.PutInt(0) }, "00"},
+		{func(jp *JsonPrinter) { jp.PutInt(127) }, "7f"},
+		{func(jp *JsonPrinter) { jp.PutInt(128) }, "cc80"},
+		{func(jp *JsonPrinter) { jp.PutInt(65536) }, "ce00010000"},
+		{func(jp *JsonPrinter) { jp.PutInt64(1 << 32) }, "cf0000000100000000"},
+		{func(jp *JsonPrinter) { jp.PutInt(-1) }, "ff"},
+		{func(jp *JsonPrinter) { jp.PutInt(-32) }, "e0"},
+		{func(jp *JsonPrinter) { jp.PutInt(-33) }, "d0df"},
+		{func(jp *JsonPrinter) { jp.PutInt(-129) }, "d1ff7f"},
+		{func(jp *JsonPrinter) { jp.PutInt64(math.MinInt64) }, "d38000000000000000"},
+		{func(jp *JsonPrinter) { jp.PutFloat(1.5) }, "ca3fc00000"},
+		{func(jp *JsonPrinter) { jp.PutFloat(1.1) }, "cb3ff199999999999a"},
+		{func(jp *JsonPrinter) { jp.PutBool(false) }, "c2"},
+		{func(jp *JsonPrinter) { jp.PutBool(true) }, "c3"},
+		{func(jp *JsonPrinter) { jp.PutNull() }, "c0"},
+		{func(jp *JsonPrinter) { jp.PutString("") }, "a0"},
+		{func(jp *JsonPrinter) { jp.PutString("ab") }, "a26162"},
+		{func(jp *JsonPrinter) { jp.PutString(long) }, "d920" + hex.EncodeToString([]byte(long))},
+		{func(jp *JsonPrinter) { jp.Arr().End() }, "90"},
+		{func(jp *JsonPrinter) { jp.Arr().Int(1).Arr().Int(2).End().End() }, "92019102"},
+		{func(jp *JsonPrinter) { jp.PutArray(many) }, "dc0010000102030405060708090a0b0c0d0e0f"},
+		{func(jp *JsonPrinter) { jp.Obj().Key("a").Int(1).End() }, "81a16101"},
+		{func(jp *JsonPrinter) { jp.PutBytes([]byte{1, 2}) }, "c4020102"},
+		{func(jp *JsonPrinter) { jp.PutValue(struct{ B []byte }{[]byte{1, 2}}) }, "81a142c4020102"},
+		// a stream of objects
+		{func(jp *JsonPrinter) { jp.Int(1).Obj().End().Str("a") }, "0180a161"},
+	}
+
+	for _, c := range cases {
+		jp := NewPrinter()
+		jp.SetFormat(MessagePackFormat)
+		c.put(jp)
+		actual, err := jp.String()
+		if err != nil || hex.EncodeToString([]byte(actual)) != c.expected {
+			t.Errorf("expected: %v\nactual: %x (%v)", c.expected, actual, err)
+		}
+	}
+}
+
+func TestMessagePackUnexpectedValue(t *testing.T) {
+	jp := NewPrinter()
+	jp.SetFormat(MessagePackFormat)
+	if err := jp.AsEmitter().Scalar(Scalar{Kind: IntScalar, Literal: "1", Value: 1}); err == nil {
+		t.Errorf("expected: error\nactual: nil")
+	}
+}
@@ -0,0 +1,215 @@
+package projson
+
+// Styles other than SimpleStyle buffer each top-level value as a tree of
+// nodes and lay it out once the value is complete, so that placement
+// decisions can take into account what follows.
+
+type nodeKind int
+
+const (
+	nodeScalar nodeKind = iota
+	nodeArray
+	nodeObject
+)
+
+type node struct {
+	kind         nodeKind
+	key          string // quoted key if the node is an object member
+	name         string // key as it was put
+	literal      string
+	colorliteral string
+	colorcode    int
+	scalar       ScalarKind  // Scalar.Kind of scalars
+	value        interface{} // Scalar.Value of scalars
+	bytes        []byte      // Scalar.bytes of scalars
+	children     []*node
+	comments     []string // put before the node
+	trailing     []string // put after the last member
+}
+
+// treeEmitter buffers each top-level value as a tree of nodes, and
+// renders it when it is complete.
+type treeEmitter struct {
+	printer  *JsonPrinter
+	stack    []*node  // open containers
+	key      string   // quoted key of the next member
+	name     string   // key of the next member as it was put
+	comments []string // put before the next node
+}
+
+func (e *treeEmitter) add(kind nodeKind, literal string, value interface{}, colorcode int) *node {
+	n := &node{
+		kind:         kind,
+		key:          e.key,
+		name:         e.name,
+		literal:      literal,
+		colorliteral: e.printer.paint(literal, colorcode),
+		colorcode:    colorcode,
+		value:        value,
+		comments:     e.comments,
+	}
+	e.key, e.name = "", ""
+	e.comments = nil
+
+	if top := len(e.stack) - 1; top >= 0 {
+		e.stack[top].children = append(e.stack[top].children, n)
+	}
+
+	return n
+}
+
+func (e *treeEmitter) begin(kind nodeKind) error {
+	e.stack = append(e.stack, e.add(kind, "", nil, colorNormal))
+	return nil
+}
+
+func (e *treeEmitter) end() error {
+	n := e.stack[len(e.stack)-1]
+	n.trailing = e.comments
+	e.comments = nil
+	e.stack = e.stack[:len(e.stack)-1]
+	if len(e.stack) == 0 {
+		return e.printer.render(n)
+	}
+	return nil
+}
+
+func (e *treeEmitter) BeginArray(count int) error {
+	return e.begin(nodeArray)
+}
+
+func (e *treeEmitter) EndArray() error {
+	return e.end()
+}
+
+func (e *treeEmitter) BeginObject(count int) error {
+	return e.begin(nodeObject)
+}
+
+func (e *treeEmitter) EndObject() error {
+	return e.end()
+}
+
+func (e *treeEmitter) Key(key string) error {
+	quoted, err := e.printer.quoteKey(key)
+	if err != nil {
+		return err
+	}
+	e.key, e.name = quoted, key
+	return nil
+}
+
+func (e *treeEmitter) Scalar(s Scalar) error {
+	n := e.add(nodeScalar, s.Literal, s.Value, s.colorcode())
+	n.scalar, n.bytes = s.Kind, s.bytes
+	if len(e.stack) == 0 {
+		return e.printer.render(n)
+	}
+	return nil
+}
+
+// empty reports whether n is a container with nothing inside.
+func (n *node) empty() bool {
+	return len(n.children) == 0 && len(n.trailing) == 0
+}
+
+func (n *node) isNumber() bool {
+	return n.kind == nodeScalar && n.literal != "" &&
+		(n.literal[0] == '-' || ('0' <= n.literal[0] && n.literal[0] <= '9'))
+}
+
+// hasObject reports whether n contains a non-empty object.
+func (n *node) hasObject() bool {
+	if n.kind == nodeObject && len(n.children) > 0 {
+		return true
+	}
+	for _, child := range n.children {
+		if child.hasObject() {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *node) brackets() (string, string) {
+	if n.kind == nodeArray {
+		return "[", "]"
+	}
+	return "{", "}"
+}
+
+// head returns the first atom of n, without and with colors.
+func (printer *JsonPrinter) head(n *node) (string, string) {
+	var text, colortext string
+
+	switch n.kind {
+	case nodeScalar:
+		text, colortext = n.literal, n.colorliteral
+	default:
+		opener, closer := n.brackets()
+		if n.empty() {
+			text, colortext = opener+closer, opener+closer
+		} else {
+			text, colortext = opener, printer.paintBracket(opener)
+		}
+	}
+
+	if n.key != "" {
+		text = n.key + ": " + text
+		colortext = printer.paint(n.key, colorKey) + ": " + colortext
+	}
+
+	return text, colortext
+}
+
+// buffered reports whether the current format and style lay out a node
+// tree.
+func (printer *JsonPrinter) buffered() bool {
+	switch printer.format {
+	case JSONFormat, JSONCFormat, JSON5Format:
+		switch printer.style {
+		case SmartStyle, PrettyStyle, HybridStyle:
+			return true
+		}
+		return false
+	case CBORFormat:
+		// streamed with indefinite-length containers
+		return false
+	}
+	return true
+}
+
+// multiDocument reports whether any number of top-level values, each
+// being a document or an item of a sequence, may be put.
+func (printer *JsonPrinter) multiDocument() bool {
+	switch printer.format {
+	case YAMLFormat, CBORFormat, MessagePackFormat:
+		return true
+	}
+	return false
+}
+
+// render lays out a complete top-level value.
+func (printer *JsonPrinter) render(n *node) error {
+	var err error
+	switch printer.format {
+	case YAMLFormat:
+		printer.renderYAML(n)
+	case TOMLFormat:
+		err = printer.renderTOML(n)
+	case MessagePackFormat:
+		err = printer.renderMessagePack(n)
+	default:
+		switch printer.style {
+		case SmartStyle:
+			printer.renderSmart(n)
+		case PrettyStyle:
+			printer.renderPretty(n)
+		case HybridStyle:
+			printer.renderHybrid(n)
+		}
+	}
+
+	printer.docs++
+	return err
+}
@@ -0,0 +1,5 @@
+//go:build !race
+
+package projson
+
+const raceEnabled = false
@@ -0,0 +1,55 @@
+package projson
+
+import (
+	"sync"
+)
+
+// Printers can be reused through a pool, saving the allocations and the
+// lookup of the terminal width done by NewPrinter, e.g. in HTTP handlers:
+//
+//	printer := projson.AcquirePrinter()
+//	defer projson.ReleasePrinter(printer)
+
+// Printers grown beyond these are left to the garbage collector rather
+// than pooled, so that a few large documents do not hold memory.
+const (
+	maxPooledBuffer = 64 << 10 // bytes of output
+	maxPooledDepth  = 256      // nesting levels
+)
+
+var printerPool sync.Pool
+
+// AcquirePrinter returns a printer in its initial state, taken from the
+// pool if there is one.
+func AcquirePrinter() *JsonPrinter {
+	if printer, ok := printerPool.Get().(*JsonPrinter); ok {
+		return printer
+	}
+	return NewPrinter()
+}
+
+// ReleasePrinter puts printer back to the pool. It must not be used after
+// that, but strings taken from it stay valid.
+func ReleasePrinter(printer *JsonPrinter) {
+	if !printer.poolable() {
+		return
+	}
+
+	// reset drops the emitters of other styles, with their trees
+	printer.reset()
+	printerPool.Put(printer)
+}
+
+// poolable reports whether printer is small enough to be pooled.
+func (printer *JsonPrinter) poolable() bool {
+	if printer.buffer.Cap() > maxPooledBuffer ||
+		cap(printer.scratch) > maxPooledBuffer ||
+		cap(printer.pathStack) > maxPooledDepth {
+		return false
+	}
+
+	if e, ok := printer.builtin.(*simpleEmitter); ok && cap(e.members) > maxPooledDepth {
+		return false
+	}
+	return true
+}
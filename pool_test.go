@@ -0,0 +1,96 @@
+package projson
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAcquirePrinter(t *testing.T) {
+	for i := 0; i < 3; i++ {
+		jp := AcquirePrinter()
+		if jp.style != SimpleStyle || jp.color || jp.buffer.Len() != 0 || len(jp.pathStack) != 0 {
+			t.Fatalf("acquired printer is not in its initial state")
+		}
+
+		jp.SetStyle(PrettyStyle)
+		jp.SetColor(true)
+		jp.BeginObject()
+		jp.PutKey("a")
+		jp.BeginArray()
+		ReleasePrinter(jp)
+	}
+
+	jp := AcquirePrinter()
+	jp.PutArray([]interface{}{1, "a"})
+	expected := `[1,"a"]`
+	actual, err := jp.String()
+	ReleasePrinter(jp)
+	if err != nil || expected != actual {
+		t.Errorf("expected: %v\nactual: %v (%v)", expected, actual, err)
+	}
+}
+
+func TestPathStackDepth(t *testing.T) {
+	const depth = 1000
+
+	jp := AcquirePrinter()
+	for i := 0; i < depth; i++ {
+		jp.BeginArray()
+	}
+	for i := 0; i < depth; i++ {
+		jp.FinishArray()
+	}
+
+	expected := strings.Repeat("[", depth) + strings.Repeat("]", depth)
+	if actual, err := jp.String(); err != nil || expected != actual {
+		t.Errorf("expected: %v\nactual: %v (%v)", expected, actual, err)
+	}
+	ReleasePrinter(jp)
+}
+
+func TestReleasePrinterSize(t *testing.T) {
+	jp := NewPrinter()
+	for i := 0; i < maxPooledDepth+1; i++ {
+		jp.BeginArray()
+	}
+	for i := 0; i < maxPooledDepth+1; i++ {
+		jp.FinishArray()
+	}
+
+	// the stack of the emitter grows as deep as the path stack
+	jp.pathStack = nil
+	if jp.poolable() {
+		t.Errorf("printer of a deep document is pooled")
+	}
+
+	jp = NewPrinter()
+	jp.SetStyle(PrettyStyle)
+	jp.BeginArray()
+	ReleasePrinter(jp)
+	if jp.builtin != nil {
+		t.Errorf("emitter of PrettyStyle is pooled")
+	}
+}
+
+func TestAcquirePrinterAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("sync.Pool does not keep printers under the race detector")
+	}
+
+	ReleasePrinter(AcquirePrinter())
+
+	allocs := testing.AllocsPerRun(100, func() {
+		jp := AcquirePrinter()
+		jp.BeginObject()
+		jp.PutKey("list")
+		jp.BeginArray()
+		jp.PutInt(1)
+		jp.PutString("two")
+		jp.FinishArray()
+		jp.FinishObject()
+		ReleasePrinter(jp)
+	})
+	if allocs > 1 {
+		t.Errorf("expected: at most 1 allocation\nactual: %v", allocs)
+	}
+}
@@ -0,0 +1,91 @@
+package projson
+
+// PrettyStyle puts each array and object on one line if it fits within
+// termwid, otherwise it puts one member per line:
+//
+//	{
+//	  "key1": "val1",
+//	  "key2": [1, 2, 3],
+//	  "key3": {"key4": 4}
+//	}
+
+const prettyIndent = 2
+
+func (printer *JsonPrinter) renderPretty(n *node) {
+	printer.renderDoc(printer.prettyDoc(n, 0, 0), 0)
+}
+
+// keyDoc returns the `"key": ` prefix of an object member, or nil. When
+// the object is broken, the prefix is padded to column.
+func (printer *JsonPrinter) keyDoc(n *node, column int) *doc {
+	if n.key == "" {
+		return nil
+	}
+
+	key := textDoc(n.key+": ", printer.paint(n.key, colorKey)+": ", printer.color)
+	width := displayWidth(n.key)
+	if width >= column || width > printer.alignwid {
+		return key
+	}
+
+	pad := indent(" ", column-width)
+	return concatDoc(key, ifBreakDoc(textDoc(pad, pad, false), textDoc("", "", false)))
+}
+
+// alignColumn returns the width keys of the members of n are padded to.
+func (printer *JsonPrinter) alignColumn(n *node) int {
+	column := 0
+	if printer.alignwid <= 0 || n.kind != nodeObject {
+		return column
+	}
+
+	for _, child := range n.children {
+		if w := displayWidth(child.key); w <= printer.alignwid && w > column {
+			column = w
+		}
+	}
+	return column
+}
+
+// prettyDoc returns the document for n at nesting depth, whose key is
+// padded to column.
+func (printer *JsonPrinter) prettyDoc(n *node, depth int, column int) *doc {
+	var value *doc
+
+	var table *doc
+	if printer.table {
+		table = printer.tableDoc(n, depth)
+	}
+
+	switch {
+	case n.kind == nodeScalar:
+		value = textDoc(n.literal, n.colorliteral, printer.color)
+	case n.empty():
+		opener, closer := n.brackets()
+		value = textDoc(opener+closer, opener+closer, printer.color)
+	case table != nil:
+		value = table
+	default:
+		opener, closer := n.brackets()
+		members := []*doc{softLineDoc()}
+		childColumn := printer.alignColumn(n)
+		for i, child := range n.children {
+			if i > 0 {
+				members = append(members, textDoc(",", ",", printer.color), lineDoc())
+			}
+			members = append(members, printer.leadingDocs(child)...)
+			members = append(members, printer.prettyDoc(child, depth+1, childColumn))
+		}
+		members = append(members, printer.trailingDocs(n)...)
+		value = groupDoc(
+			textDoc(opener, printer.paintBracket(opener), printer.color),
+			nestDoc(prettyIndent, members...),
+			softLineDoc(),
+			textDoc(closer, printer.paintBracket(closer), printer.color))
+	}
+
+	if key := printer.keyDoc(n, column); key != nil {
+		return concatDoc(key, value)
+	}
+	return value
+}
@@ -0,0 +1,206 @@
+package projson
+
+import "testing"
+
+const prettyTestDoc = `{"key": "val", "list": [1, 2, 3, 4], "empty": {}, "nested": {"key4": 1.5, "deeper": {"a": [10, 20], "b": "text"}}, "last": [[]]}`
+
+func TestPrettyStyle(t *testing.T) {
+	cases := []struct {
+		width    int
+		expected string
+	}{
+		{200, `{"key": "val", "list": [1, 2, 3, 4], "empty": {}, "nested": {"key4": 1.5, "deeper": {"a": [10, 20], "b": "text"}}, "last": [[]]}`},
+		{80, `{
+  "key": "val",
+  "list": [1, 2, 3, 4],
+  "empty": {},
+  "nested": {"key4": 1.5, "deeper": {"a": [10, 20], "b": "text"}},
+  "last": [[]]
+}`},
+		{44, `{
+  "key": "val",
+  "list": [1, 2, 3, 4],
+  "empty": {},
+  "nested": {
+    "key4": 1.5,
+    "deeper": {"a": [10, 20], "b": "text"}
+  },
+  "last": [[]]
+}`},
+		{24, `{
+  "key": "val",
+  "list": [1, 2, 3, 4],
+  "empty": {},
+  "nested": {
+    "key4": 1.5,
+    "deeper": {
+      "a": [10, 20],
+      "b": "text"
+    }
+  },
+  "last": [[]]
+}`},
+		{16, `{
+  "key": "val",
+  "list": [
+    1,
+    2,
+    3,
+    4
+  ],
+  "empty": {},
+  "nested": {
+    "key4": 1.5,
+    "deeper": {
+      "a": [
+        10,
+        20
+      ],
+      "b": "text"
+    }
+  },
+  "last": [[]]
+}`},
+	}
+
+	for _, c := range cases {
+		jp := NewPrinter()
+		jp.SetStyle(PrettyStyle)
+		jp.SetTermWidth(c.width)
+		if err := putJSON(jp, prettyTestDoc); err != nil {
+			t.Fatal(err)
+		}
+
+		actual, _ := jp.String()
+		if c.expected != actual {
+			t.Errorf("width %d\nexpected: %v\nactual: %v", c.width, c.expected, actual)
+		}
+	}
+}
+
+func TestPrettyStyleTrailingContext(t *testing.T) {
+	// the comma after the inner array counts towards its width
+	jp := NewPrinter()
+	jp.SetStyle(PrettyStyle)
+	jp.SetTermWidth(9)
+
+	jp.BeginArray()
+	jp.PutArray([]interface{}{1, 2})
+	jp.PutInt(3)
+	jp.FinishArray()
+
+	expected := `[
+  [1, 2],
+  3
+]`
+	if actual, _ := jp.String(); expected != actual {
+		t.Errorf("expected: %v\nactual: %v", expected, actual)
+	}
+
+	jp.Reset()
+	jp.SetStyle(PrettyStyle)
+	jp.SetTermWidth(8)
+
+	jp.BeginArray()
+	jp.PutArray([]interface{}{1, 2})
+	jp.PutInt(3)
+	jp.FinishArray()
+
+	expected = `[
+  [
+    1,
+    2
+  ],
+  3
+]`
+	if actual, _ := jp.String(); expected != actual {
+		t.Errorf("expected: %v\nactual: %v", expected, actual)
+	}
+}
+
+func TestPrettyStyleColor(t *testing.T) {
+	jp := NewPrinter()
+	jp.SetStyle(PrettyStyle)
+	jp.SetTermWidth(14)
+	jp.SetColor(true)
+
+	jp.BeginObject()
+	jp.PutKey("k")
+	jp.PutInt(1)
+	jp.PutKey("s")
+	jp.PutString("str")
+	jp.FinishObject()
+
+	// escapes do not count: {"k": 1, "s": "str"} is 20 cells wide
+	expected := "{\n  " + color(`"k"`, colorKey) + ": " + color("1", colorInt) + ",\n  " +
+		color(`"s"`, colorKey) + ": " + color(`"str"`, colorString) + "\n}"
+	if actual, _ := jp.String(); expected != actual {
+		t.Errorf("expected: %v\nactual: %v", expected, actual)
+	}
+}
+
+func TestPrettyLineWidthProperty(t *testing.T) {
+	// indentation takes up to 2*(depth+1), plus the trailing comma
+	checkLineWidths(t, PrettyStyle, 13)
+}
+
+func TestValueAlignment(t *testing.T) {
+	src := `{"id": 1, "name": "alice", "a-very-long-key-name": "yes", "tags": {"x": 1, "yy": 2}, "住所": "東京"}`
+
+	cases := []struct {
+		style    int
+		width    int
+		expected string
+	}{
+		{PrettyStyle, 40, `{
+  "id":   1,
+  "name": "alice",
+  "a-very-long-key-name": "yes",
+  "tags": {"x": 1, "yy": 2},
+  "住所": "東京"
+}`},
+		{PrettyStyle, 12, `{
+  "id":   1,
+  "name": "alice",
+  "a-very-long-key-name": "yes",
+  "tags": {
+    "x":  1,
+    "yy": 2
+  },
+  "住所": "東京"
+}`},
+		{HybridStyle, 80, `{
+  "id":   1,
+  "name": "alice",
+  "a-very-long-key-name": "yes",
+  "tags": {
+    "x":  1,
+    "yy": 2
+  },
+  "住所": "東京"
+}`},
+		// fits on one line: no padding
+		{PrettyStyle, 120, `{"id": 1, "name": "alice", "a-very-long-key-name": "yes", "tags": {"x": 1, "yy": 2}, "住所": "東京"}`},
+	}
+
+	for _, c := range cases {
+		jp := NewPrinter()
+		jp.SetStyle(c.style)
+		jp.SetTermWidth(c.width)
+		jp.SetValueAlignment(8)
+		if err := putJSON(jp, src); err != nil {
+			t.Fatal(err)
+		}
+
+		actual, _ := jp.String()
+		if c.expected != actual {
+			t.Errorf("style %d, width %d\nexpected: %v\nactual: %v", c.style, c.width, c.expected, actual)
+		}
+	}
+
+	jp := NewPrinter()
+	jp.PutInt(1)
+	if err := jp.SetValueAlignment(8); err == nil {
+		t.Error("SetValueAlignment should return error after putting items")
+	}
+}
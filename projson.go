@@ -2,13 +2,14 @@ package projson
 
 import (
 	"bytes"
-	"container/list"
-	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"strconv"
+	"strings"
+	"unicode/utf8"
 )
 
 type printerState int
@@ -35,27 +36,69 @@ const (
 	colorMagenta = 35
 	colorCyan    = 36
 	colorWhite   = 37
+	colorGray    = 90
+
+	colorBrightRed     = 91
+	colorBrightGreen   = 92
+	colorBrightYellow  = 93
+	colorBrightBlue    = 94
+	colorBrightMagenta = 95
+	colorBrightCyan    = 96
 )
 
 const (
-	colorKey    = colorRed
-	colorInt    = colorGreen
-	colorFloat  = colorCyan
-	colorString = colorMagenta
+	colorKey     = colorRed
+	colorInt     = colorGreen
+	colorFloat   = colorCyan
+	colorString  = colorMagenta
+	colorBool    = colorYellow
+	colorNull    = colorBlue
+	colorComment = colorGray
+
+	colorTime     = colorBrightCyan
+	colorDuration = colorBrightGreen
+	colorBytes    = colorBrightMagenta
+	colorIP       = colorBrightYellow
+	colorURL      = colorBrightBlue
+	colorUUID     = colorBrightRed
 )
 
 type JsonPrinter struct {
-	state     printerState
-	pathStack *list.List
-	buffer    *bytes.Buffer
-	style     int
-	termwid   int
-	color     bool
-	err       error
+	state       printerState
+	pathStack   []pathStackFrame
+	buffer      *bytes.Buffer
+	style       int
+	format      int
+	termwid     int
+	systermwid  int // terminal width found by NewPrinter or Reset
+	color       bool
+	colormode   int
+	collapsible bool // collapsible containers in HTML color mode
+	alignwid    int  // maximum key width for aligned object values
+	table       bool // table layout of arrays of records
+	yamlflow    int  // nesting depth from which YAML is written in flow style
+	tomlstrict  bool // reject arrays TOML 0.5 cannot represent
+	canonical   bool // RFC 8785 canonical JSON
+	json5       int  // JSON5 options
+	nocomments  bool // reject comments in formats without them
+	newline     bool // end the output with a newline
+	timefmt     int
+	timelayout  string // of TimeLayout
+	durationfmt int
+	bytesfmt    int
+	escapeHTML  bool
+	asciiOnly   bool
+	strictUTF8  bool
+	scratch     []byte // reused by quote
+	docs        int    // number of top-level values written
+	err         error
+
+	emitter   Emitter // set by SetEmitter
+	builtin   Emitter // rendering in style and format
+	validator *schemaValidator
 
 	// position in current line (used for smart style)
 	linepos int
-	curKey  string
 }
 
 type frameType int
@@ -69,6 +112,22 @@ const (
 	SimpleStyle int = iota
 	SmartStyle
 	PrettyStyle
+	HybridStyle
+)
+
+const (
+	JSONFormat int = iota
+	YAMLFormat
+	TOMLFormat
+	CBORFormat
+	MessagePackFormat
+	JSONCFormat // JSON with comments
+	JSON5Format
+)
+
+const (
+	ANSIColor int = iota
+	HTMLColor
 )
 
 type pathStackFrame struct {
@@ -93,47 +152,137 @@ func getSystemTermWidth() int {
 }
 
 func NewPrinter() *JsonPrinter {
+	termwid := getSystemTermWidth()
 	printer := &JsonPrinter{
-		state:     stateInit,
-		pathStack: list.New(),
-		buffer:    bytes.NewBuffer([]byte{}),
-		style:     SimpleStyle,
-		termwid:   getSystemTermWidth(),
-		color:     false,
-		err:       nil,
-		linepos:   0,
+		state:       stateInit,
+		pathStack:   nil,
+		buffer:      bytes.NewBuffer([]byte{}),
+		style:       SimpleStyle,
+		format:      JSONFormat,
+		termwid:     termwid,
+		systermwid:  termwid,
+		color:       false,
+		colormode:   ANSIColor,
+		collapsible: false,
+		alignwid:    0,
+		table:       false,
+		yamlflow:    -1,
+		tomlstrict:  false,
+		canonical:   false,
+		json5:       0,
+		nocomments:  false,
+		newline:     false,
+		timefmt:     TimeRFC3339,
+		timelayout:  "",
+		durationfmt: DurationSeconds,
+		bytesfmt:    BytesBase64,
+		escapeHTML:  true,
+		asciiOnly:   false,
+		strictUTF8:  false,
+		scratch:     nil,
+		docs:        0,
+		emitter:     nil,
+		builtin:     nil,
+		err:         nil,
+		linepos:     0,
 	}
 
 	return printer
 }
 
 func (printer *JsonPrinter) Reset() {
+	printer.systermwid = getSystemTermWidth()
+	printer.reset()
+}
+
+// reset brings printer back to its initial state, keeping the capacity of
+// its buffers.
+func (printer *JsonPrinter) reset() {
 	printer.state = stateInit
-	printer.pathStack = list.New()
-	printer.buffer = bytes.NewBuffer([]byte{})
+	printer.pathStack = printer.pathStack[:0]
+	printer.buffer.Reset()
 	printer.style = SimpleStyle
-	printer.termwid = getSystemTermWidth()
+	printer.format = JSONFormat
+	printer.termwid = printer.systermwid
 	printer.color = false
+	printer.colormode = ANSIColor
+	printer.collapsible = false
+	printer.alignwid = 0
+	printer.table = false
+	printer.yamlflow = -1
+	printer.tomlstrict = false
+	printer.canonical = false
+	printer.json5 = 0
+	printer.nocomments = false
+	printer.newline = false
+	printer.timefmt = TimeRFC3339
+	printer.timelayout = ""
+	printer.durationfmt = DurationSeconds
+	printer.bytesfmt = BytesBase64
+	printer.escapeHTML = true
+	printer.asciiOnly = false
+	printer.strictUTF8 = false
+	printer.docs = 0
+	printer.emitter = nil
+	printer.validator = nil
 	printer.err = nil
 	printer.linepos = 0
+
+	// the emitter of the initial style can be kept, with its stack
+	if e, ok := printer.builtin.(*simpleEmitter); ok {
+		e.members = e.members[:0]
+		e.keyed = false
+		e.comments = nil
+	} else {
+		printer.builtin = nil
+	}
 }
 
 func (printer *JsonPrinter) Error() error {
 	return printer.err
 }
 
+// SetStyle sets the style of the output. Like the other Set* methods, it
+// fails once some items have been put, and the error is kept as the
+// printer's.
 func (printer *JsonPrinter) SetStyle(style int) error {
+	if printer.err != nil {
+		return printer.err
+	}
+
 	if printer.state != stateInit {
-		return errors.New("Style cannot changed after putting some items")
+		printer.err = errors.New("Style cannot changed after putting some items")
+		return printer.err
 	}
 
 	printer.style = style
+	printer.builtin = nil
+	return nil
+}
+
+func (printer *JsonPrinter) SetFormat(format int) error {
+	if printer.err != nil {
+		return printer.err
+	}
+
+	if printer.state != stateInit {
+		printer.err = errors.New("Format cannot changed after putting some items")
+		return printer.err
+	}
+
+	printer.format = format
+	printer.builtin = nil
 	return nil
 }
 
 func (printer *JsonPrinter) SetTermWidth(termwid int) error {
+	if printer.err != nil {
+		return printer.err
+	}
+
 	if printer.state != stateInit {
-		return errors.New("Terminal width cannot changed after putting some items")
+		printer.err = errors.New("Terminal width cannot changed after putting some items")
+		return printer.err
 	}
 
 	printer.termwid = termwid
@@ -141,36 +290,251 @@ func (printer *JsonPrinter) SetTermWidth(termwid int) error {
 }
 
 func (printer *JsonPrinter) SetColor(color bool) error {
+	if printer.err != nil {
+		return printer.err
+	}
+
 	if printer.state != stateInit {
-		return errors.New("Color mode cannot changed after putting some items")
+		printer.err = errors.New("Color mode cannot changed after putting some items")
+		return printer.err
 	}
 
 	printer.color = color
 	return nil
 }
 
+// SetColorMode sets how SetColor(true) colors the output: with ANSI
+// escape sequences, or with HTML <span> elements of classes json-key,
+// json-string, json-number, json-bool and json-null.
+func (printer *JsonPrinter) SetColorMode(mode int) error {
+	if printer.err != nil {
+		return printer.err
+	}
+
+	if printer.state != stateInit {
+		printer.err = errors.New("Color mode cannot changed after putting some items")
+		return printer.err
+	}
+
+	printer.colormode = mode
+	return nil
+}
+
+// SetCollapsible makes HTMLColor mode wrap non-empty arrays and objects
+// in <details open> elements, with the opening bracket as the summary, in
+// styles that break lines.
+func (printer *JsonPrinter) SetCollapsible(collapsible bool) error {
+	if printer.err != nil {
+		return printer.err
+	}
+
+	if printer.state != stateInit {
+		printer.err = errors.New("Collapsible mode cannot changed after putting some items")
+		return printer.err
+	}
+
+	printer.collapsible = collapsible
+	return nil
+}
+
+// SetValueAlignment makes PrettyStyle and HybridStyle align the values
+// of expanded objects in a column. Keys wider than maxKeyWidth (counting
+// the quotes) are left out of the alignment. 0 disables it.
+func (printer *JsonPrinter) SetValueAlignment(maxKeyWidth int) error {
+	if printer.err != nil {
+		return printer.err
+	}
+
+	if printer.state != stateInit {
+		printer.err = errors.New("Value alignment cannot changed after putting some items")
+		return printer.err
+	}
+
+	printer.alignwid = maxKeyWidth
+	return nil
+}
+
+// SetTableMode makes PrettyStyle and HybridStyle put arrays of flat
+// objects with the same keys as tables, one object per line with aligned
+// members.
+func (printer *JsonPrinter) SetTableMode(table bool) error {
+	if printer.err != nil {
+		return printer.err
+	}
+
+	if printer.state != stateInit {
+		printer.err = errors.New("Table mode cannot changed after putting some items")
+		return printer.err
+	}
+
+	printer.table = table
+	return nil
+}
+
+// SetYAMLFlowLevel makes YAMLFormat write containers nested level or more
+// deep in flow style. A negative level writes all of them in block style.
+func (printer *JsonPrinter) SetYAMLFlowLevel(level int) error {
+	if printer.err != nil {
+		return printer.err
+	}
+
+	if printer.state != stateInit {
+		printer.err = errors.New("YAML flow level cannot changed after putting some items")
+		return printer.err
+	}
+
+	printer.yamlflow = level
+	return nil
+}
+
+// SetTOMLStrict makes TOMLFormat fail on arrays of mixed types, which TOML
+// 0.5 does not allow.
+func (printer *JsonPrinter) SetTOMLStrict(strict bool) error {
+	if printer.err != nil {
+		return printer.err
+	}
+
+	if printer.state != stateInit {
+		printer.err = errors.New("TOML strict mode cannot changed after putting some items")
+		return printer.err
+	}
+
+	printer.tomlstrict = strict
+	return nil
+}
+
+// SetCanonical makes the printer put RFC 8785 canonical JSON, whatever
+// its style, format and color settings are.
+func (printer *JsonPrinter) SetCanonical(canonical bool) error {
+	if printer.err != nil {
+		return printer.err
+	}
+
+	if printer.state != stateInit {
+		printer.err = errors.New("Canonical mode cannot changed after putting some items")
+		return printer.err
+	}
+
+	printer.canonical = canonical
+	printer.builtin = nil
+	return nil
+}
+
+// SetFinalNewline makes the output end with a newline, in text formats.
+func (printer *JsonPrinter) SetFinalNewline(newline bool) error {
+	if printer.err != nil {
+		return printer.err
+	}
+
+	if printer.state != stateInit {
+		printer.err = errors.New("Final newline cannot changed after putting some items")
+		return printer.err
+	}
+
+	printer.newline = newline
+	return nil
+}
+
 func (printer *JsonPrinter) String() (string, error) {
-	if printer.state == stateInit || printer.state == stateFinal {
-		return printer.buffer.String(), nil
+	out, err := printer.output()
+	if err != nil {
+		return "", err
 	}
+	return string(out), nil
+}
 
-	return "", errors.New("Some object/array is not finished.")
+// Bytes returns the output, valid until the next change to the printer.
+func (printer *JsonPrinter) Bytes() ([]byte, error) {
+	return printer.output()
 }
 
-func indent(str string, n int) string {
-	buffer := bytes.NewBuffer([]byte{})
-	for i := 0; i < n; i++ {
-		buffer.WriteString(str)
+// WriteTo writes the output to w and removes it from the printer, so
+// that it can be called after each document.
+func (printer *JsonPrinter) WriteTo(w io.Writer) (int64, error) {
+	if _, err := printer.output(); err != nil {
+		return 0, err
+	}
+	return printer.buffer.WriteTo(w)
+}
+
+// output returns the output, which must be complete.
+func (printer *JsonPrinter) output() ([]byte, error) {
+	if printer.err != nil {
+		return nil, printer.err
+	}
+
+	if printer.state != stateInit && printer.state != stateFinal {
+		return nil, errors.New("Some object/array is not finished.")
+	}
+
+	out := printer.buffer.Bytes()
+	if printer.newline && len(out) > 0 && out[len(out)-1] != '\n' &&
+		printer.format != CBORFormat && printer.format != MessagePackFormat {
+		printer.buffer.WriteByte('\n')
+		printer.linepos = 0
+		out = printer.buffer.Bytes()
 	}
 
-	return buffer.String()
+	return out, nil
+}
+
+func indent(str string, n int) string {
+	return strings.Repeat(str, n)
 }
 
 func color(str string, colorcode int) string {
-	return fmt.Sprintf("\033[%dm%s\033[0m", colorcode, str)
+	return "\033[" + strconv.Itoa(colorcode) + "m" + str + "\033[0m"
+}
+
+var htmlClasses = map[int]string{
+	colorBold:    "json-bold",
+	colorBlack:   "json-black",
+	colorWhite:   "json-white",
+	colorKey:     "json-key",
+	colorInt:     "json-number",
+	colorFloat:   "json-number",
+	colorString:  "json-string",
+	colorBool:    "json-bool",
+	colorNull:    "json-null",
+	colorComment: "json-comment",
+
+	colorTime:     "json-time",
+	colorDuration: "json-duration",
+	colorBytes:    "json-bytes",
+	colorIP:       "json-ip",
+	colorURL:      "json-url",
+	colorUUID:     "json-uuid",
+}
+
+var htmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// paint colors str in the color mode of the printer.
+func (printer *JsonPrinter) paint(str string, colorcode int) string {
+	if printer.colormode == HTMLColor {
+		return `<span class="` + htmlClasses[colorcode] + `">` + htmlEscaper.Replace(str) + "</span>"
+	}
+	return color(str, colorcode)
+}
+
+// paintBracket returns the colored text of the bracket of a non-empty
+// container.
+func (printer *JsonPrinter) paintBracket(bracket string) string {
+	if printer.colormode != HTMLColor || !printer.collapsible {
+		return bracket
+	}
+
+	switch bracket {
+	case "[", "{":
+		return "<details open><summary>" + bracket + "</summary>"
+	}
+	return bracket + "</details>"
 }
 
 func (printer *JsonPrinter) BeginArray() error {
+	return printer.beginArray(-1)
+}
+
+func (printer *JsonPrinter) beginArray(count int) error {
 	if printer.err != nil {
 		return printer.err
 	}
@@ -187,75 +551,18 @@ func (printer *JsonPrinter) BeginArray() error {
 	}
 
 	var cur_level int
-	if printer.pathStack.Len() == 0 {
+	if len(printer.pathStack) == 0 {
 		cur_level = 0
 	} else {
-		cur_level = printer.pathStack.Back().Value.(*pathStackFrame).level
-	}
-
-	var newchunk string
-	var colorchunk string
-	if printer.style == SmartStyle {
-		if printer.state == stateObject0Keyed {
-			newchunk = fmt.Sprintf("%s: [", printer.curKey)
-			colorchunk = fmt.Sprintf("%s: [", color(printer.curKey, colorKey))
-			if printer.color {
-				printer.buffer.WriteString(colorchunk)
-			} else {
-				printer.buffer.WriteString(newchunk)
-			}
-			printer.linepos += len(newchunk)
-			printer.curKey = ""
-		} else if printer.state == stateObject1Keyed {
-			newchunk = fmt.Sprintf(",\n%s%s: [", indent(" ", cur_level), printer.curKey)
-			colorchunk = fmt.Sprintf(",\n%s%s: [", indent(" ", cur_level), color(printer.curKey, colorKey))
-			if printer.color {
-				printer.buffer.WriteString(colorchunk)
-			} else {
-				printer.buffer.WriteString(newchunk)
-			}
-			printer.linepos += len(newchunk) - 2 + len(indent(" ", cur_level))
-			printer.curKey = ""
-		} else if printer.state == stateInit || printer.state == stateArray0 {
-			newchunk = fmt.Sprintf("[")
-			printer.buffer.WriteString(newchunk)
-			printer.linepos += len(newchunk)
-		} else if printer.state == stateArray1 {
-			newchunk = fmt.Sprintf(", [")
-			printer.buffer.WriteString(newchunk)
-			printer.linepos += len(newchunk)
-		}
+		cur_level = printer.pathStack[len(printer.pathStack)-1].level
+	}
 
-		if printer.linepos >= printer.termwid {
-			printer.buffer.WriteString("\n" + indent(" ", cur_level+1))
-			printer.linepos = cur_level + 1
-		}
-	} else {
-		switch printer.state {
-		case stateInit:
-			printer.buffer.WriteString("[")
-		case stateArray0:
-			printer.buffer.WriteString("[")
-		case stateArray1:
-			printer.buffer.WriteString(",[")
-		case stateObject0Keyed:
-			if printer.color {
-				printer.buffer.WriteString(fmt.Sprintf("%s:[", color(printer.curKey, colorKey)))
-			} else {
-				printer.buffer.WriteString(fmt.Sprintf("%s:[", printer.curKey))
-			}
-			printer.curKey = ""
-		case stateObject1Keyed:
-			if printer.color {
-				printer.buffer.WriteString(fmt.Sprintf(",%s:[", color(printer.curKey, colorKey)))
-			} else {
-				printer.buffer.WriteString(fmt.Sprintf(",%s:[", printer.curKey))
-			}
-			printer.curKey = ""
-		}
+	if err := printer.out().BeginArray(count); err != nil {
+		printer.err = err
+		return printer.err
 	}
 
-	printer.pathStack.PushBack(&pathStackFrame{typ: frameArray, level: cur_level + 1})
+	printer.pathStack = append(printer.pathStack, pathStackFrame{typ: frameArray, level: cur_level + 1})
 	printer.state = stateArray0
 
 	return nil
@@ -274,42 +581,23 @@ func (printer *JsonPrinter) FinishArray() error {
 		return printer.err
 	}
 
-	if printer.pathStack.Len() == 0 ||
-		printer.pathStack.Back().Value.(*pathStackFrame).typ != frameArray {
+	if len(printer.pathStack) == 0 ||
+		printer.pathStack[len(printer.pathStack)-1].typ != frameArray {
 		printer.err = errors.New("No array stack frame found")
 		return printer.err
 	}
 
-	cur_level := printer.pathStack.Back().Value.(*pathStackFrame).level
+	printer.pathStack = printer.pathStack[:len(printer.pathStack)-1]
 
-	if printer.style == SmartStyle {
-		if cur_level == 1 {
-			if printer.linepos+1 > printer.termwid {
-				printer.buffer.WriteString("\n")
-				printer.linepos = 0
-				printer.buffer.WriteString(" ")
-				printer.linepos += 1
-			}
-		} else {
-			if printer.linepos+2 > printer.termwid {
-				printer.buffer.WriteString("\n")
-				printer.linepos = 0
-				for i := 0; i < cur_level; i++ {
-					printer.buffer.WriteString(" ")
-					printer.linepos += 1
-				}
-			}
-		}
+	if err := printer.out().EndArray(); err != nil {
+		printer.err = err
+		return printer.err
 	}
 
-	printer.buffer.WriteString("]")
-	printer.linepos += 1
-	printer.pathStack.Remove(printer.pathStack.Back())
-
-	if printer.pathStack.Len() == 0 {
+	if len(printer.pathStack) == 0 {
 		printer.state = stateInit
 	} else {
-		switch printer.pathStack.Back().Value.(*pathStackFrame).typ {
+		switch printer.pathStack[len(printer.pathStack)-1].typ {
 		case frameArray:
 			printer.state = stateArray1
 		case frameObject:
@@ -324,7 +612,7 @@ func (printer *JsonPrinter) FinishArray() error {
 }
 
 func (printer *JsonPrinter) PutArray(arr []interface{}) error {
-	if err := printer.BeginArray(); err != nil {
+	if err := printer.beginArray(len(arr)); err != nil {
 		return err
 	}
 
@@ -346,8 +634,17 @@ func (printer *JsonPrinter) PutArray(arr []interface{}) error {
 			if err := printer.PutString(v.(string)); err != nil {
 				return err
 			}
+		case bool:
+			if err := printer.PutBool(v.(bool)); err != nil {
+				return err
+			}
+		case nil:
+			if err := printer.PutNull(); err != nil {
+				return err
+			}
 		default:
-			return errors.New("unknown type in array")
+			printer.err = errors.New("unknown type in array")
+			return printer.err
 		}
 	}
 
@@ -359,6 +656,10 @@ func (printer *JsonPrinter) PutArray(arr []interface{}) error {
 }
 
 func (printer *JsonPrinter) BeginObject() error {
+	return printer.beginObject(-1)
+}
+
+func (printer *JsonPrinter) beginObject(count int) error {
 	if printer.err != nil {
 		return printer.err
 	}
@@ -375,70 +676,18 @@ func (printer *JsonPrinter) BeginObject() error {
 	}
 
 	var cur_level int
-	if printer.pathStack.Len() == 0 {
+	if len(printer.pathStack) == 0 {
 		cur_level = 0
 	} else {
-		cur_level = printer.pathStack.Back().Value.(*pathStackFrame).level
-	}
-
-	var newchunk string
-	var colorchunk string
-	if printer.style == SmartStyle {
-		if printer.state == stateObject0Keyed {
-			newchunk = fmt.Sprintf("%s: {", printer.curKey)
-			colorchunk = fmt.Sprintf("%s: {", color(printer.curKey, colorKey))
-			if printer.color {
-				printer.buffer.WriteString(colorchunk)
-			} else {
-				printer.buffer.WriteString(newchunk)
-			}
-			printer.linepos = len(newchunk) - 1 + len(indent(" ", cur_level))
-			printer.curKey = ""
-		} else if printer.state == stateObject1Keyed {
-			newchunk = fmt.Sprintf(",\n%s%s: {", indent(" ", cur_level), printer.curKey)
-			colorchunk = fmt.Sprintf(",\n%s%s: {", indent(" ", cur_level), color(printer.curKey, colorKey))
-			if printer.color {
-				printer.buffer.WriteString(colorchunk)
-			} else {
-				printer.buffer.WriteString(newchunk)
-			}
-			printer.linepos = len(newchunk) - 2 + len(indent(" ", cur_level))
-			printer.curKey = ""
-		} else if printer.state == stateInit || printer.state == stateArray0 {
-			newchunk = fmt.Sprintf("{")
-			printer.buffer.WriteString(newchunk)
-			printer.linepos += len(newchunk)
-		} else if printer.state == stateArray1 {
-			newchunk = fmt.Sprintf(", {")
-			printer.buffer.WriteString(newchunk)
-			printer.linepos += len(newchunk)
-		}
-	} else {
-		switch printer.state {
-		case stateInit:
-			printer.buffer.WriteString("{")
-		case stateArray0:
-			printer.buffer.WriteString("{")
-		case stateArray1:
-			printer.buffer.WriteString(",{")
-		case stateObject0Keyed:
-			if printer.color {
-				printer.buffer.WriteString(fmt.Sprintf("%s:{", color(printer.curKey, colorKey)))
-			} else {
-				printer.buffer.WriteString(fmt.Sprintf("%s:{", printer.curKey))
-			}
-			printer.curKey = ""
-		case stateObject1Keyed:
-			if printer.color {
-				printer.buffer.WriteString(fmt.Sprintf(",%s:{", color(printer.curKey, colorKey)))
-			} else {
-				printer.buffer.WriteString(fmt.Sprintf(",%s:{", printer.curKey))
-			}
-			printer.curKey = ""
-		}
+		cur_level = printer.pathStack[len(printer.pathStack)-1].level
+	}
+
+	if err := printer.out().BeginObject(count); err != nil {
+		printer.err = err
+		return printer.err
 	}
 
-	printer.pathStack.PushBack(&pathStackFrame{typ: frameObject, level: cur_level + 1})
+	printer.pathStack = append(printer.pathStack, pathStackFrame{typ: frameObject, level: cur_level + 1})
 	printer.state = stateObject0
 
 	return nil
@@ -457,20 +706,23 @@ func (printer *JsonPrinter) FinishObject() error {
 		return printer.err
 	}
 
-	if printer.pathStack.Len() == 0 ||
-		printer.pathStack.Back().Value.(*pathStackFrame).typ != frameObject {
+	if len(printer.pathStack) == 0 ||
+		printer.pathStack[len(printer.pathStack)-1].typ != frameObject {
 		printer.err = errors.New("No object stack frame found")
 		return printer.err
 	}
 
-	printer.buffer.WriteString("}")
-	printer.linepos += 1
-	printer.pathStack.Remove(printer.pathStack.Back())
+	printer.pathStack = printer.pathStack[:len(printer.pathStack)-1]
+
+	if err := printer.out().EndObject(); err != nil {
+		printer.err = err
+		return printer.err
+	}
 
-	if printer.pathStack.Len() == 0 {
+	if len(printer.pathStack) == 0 {
 		printer.state = stateInit
 	} else {
-		switch printer.pathStack.Back().Value.(*pathStackFrame).typ {
+		switch printer.pathStack[len(printer.pathStack)-1].typ {
 		case frameArray:
 			printer.state = stateArray1
 		case frameObject:
@@ -485,7 +737,7 @@ func (printer *JsonPrinter) FinishObject() error {
 }
 
 func (printer *JsonPrinter) PutObject(m map[string]interface{}) error {
-	if err := printer.BeginObject(); err != nil {
+	if err := printer.beginObject(len(m)); err != nil {
 		return err
 	}
 
@@ -507,8 +759,17 @@ func (printer *JsonPrinter) PutObject(m map[string]interface{}) error {
 			if err := printer.PutString(v.(string)); err != nil {
 				return err
 			}
+		case bool:
+			if err := printer.PutBool(v.(bool)); err != nil {
+				return err
+			}
+		case nil:
+			if err := printer.PutNull(); err != nil {
+				return err
+			}
 		default:
-			return errors.New("unknown type in array")
+			printer.err = errors.New("unknown type in object")
+			return printer.err
 		}
 	}
 
@@ -519,104 +780,45 @@ func (printer *JsonPrinter) PutObject(m map[string]interface{}) error {
 	return nil
 }
 
-func (printer *JsonPrinter) putLiteral(literal string, colorliteral string) error {
-	switch printer.state {
-	case stateInit: // OK
-	case stateArray0: // OK
-	case stateArray1: // OK
-	case stateObject0Keyed: // OK
-	case stateObject1Keyed: // OK
-	default:
-		printer.err = errors.New("Cannot put literal (" + literal + ") in this context")
+func (printer *JsonPrinter) putScalar(scalar Scalar) error {
+	if printer.err != nil {
 		return printer.err
 	}
 
-	var cur_level int
-	if printer.pathStack.Len() == 0 {
-		cur_level = 0
-	} else {
-		cur_level = printer.pathStack.Back().Value.(*pathStackFrame).level
+	if !printer.scalarAllowed() {
+		printer.err = errors.New("Cannot put literal (" + scalar.Literal + ") in this context")
+		return printer.err
 	}
 
-	var newchunk string
-	var colorchunk string
-	commasep := false
-	switch printer.state {
-	case stateInit:
-		newchunk = literal
-		colorchunk = colorliteral
-	case stateArray0:
-		newchunk = literal
-		colorchunk = colorliteral
-	case stateArray1:
-		commasep = true
-		newchunk = literal
-		colorchunk = colorliteral
-	case stateObject0Keyed:
-		if printer.style == SmartStyle {
-			newchunk = fmt.Sprintf("%s: %s", printer.curKey, literal)
-			colorchunk = fmt.Sprintf("%s: %s", color(printer.curKey, colorKey), colorliteral)
-			printer.curKey = ""
-		} else {
-			newchunk = fmt.Sprintf("%s:%s", printer.curKey, literal)
-			colorchunk = fmt.Sprintf("%s:%s", color(printer.curKey, colorKey), colorliteral)
-			printer.curKey = ""
-		}
-	case stateObject1Keyed:
-		commasep = true
-		if printer.style == SmartStyle {
-			newchunk = fmt.Sprintf("%s: %s", printer.curKey, literal)
-			colorchunk = fmt.Sprintf("%s: %s", color(printer.curKey, colorKey), colorliteral)
-			printer.curKey = ""
-		} else {
-			newchunk = fmt.Sprintf("%s:%s", printer.curKey, literal)
-			colorchunk = fmt.Sprintf("%s:%s", color(printer.curKey, colorKey), colorliteral)
-			printer.curKey = ""
-		}
+	if err := printer.out().Scalar(scalar); err != nil {
+		printer.err = err
+		return printer.err
 	}
 
-	if printer.style == SmartStyle {
-		commalen := 0
-		if commasep {
-			commalen = 2
-		}
-
-		if printer.linepos+len(newchunk)+commalen >= printer.termwid+1 {
-			if commasep {
-				printer.buffer.WriteString(",\n")
-				printer.buffer.WriteString(indent(" ", cur_level))
-				printer.linepos = cur_level
-			}
-		} else {
-			if commasep {
-				printer.buffer.WriteString(", ")
-				printer.linepos += 2
-			}
-		}
-
-		if printer.color {
-			printer.buffer.WriteString(colorchunk)
-		} else {
-			printer.buffer.WriteString(newchunk)
-		}
+	printer.scalarPut()
+	return nil
+}
 
-		printer.linepos += len(newchunk)
-	} else {
-		if commasep {
-			printer.buffer.WriteString(",")
-		}
-		if printer.color {
-			printer.buffer.WriteString(colorchunk)
-		} else {
-			printer.buffer.WriteString(newchunk)
-		}
-		printer.linepos += len(newchunk)
+func (printer *JsonPrinter) scalarAllowed() bool {
+	switch printer.state {
+	case stateInit: // OK
+	case stateArray0: // OK
+	case stateArray1: // OK
+	case stateObject0Keyed: // OK
+	case stateObject1Keyed: // OK
+	default:
+		return false
 	}
+	return true
+}
 
-	// state transitions
+// scalarPut makes the state transition after putting a scalar.
+func (printer *JsonPrinter) scalarPut() {
 	switch printer.state {
 	case stateInit:
-		printer.state = stateFinal
+		if !printer.multiDocument() {
+			printer.state = stateFinal
+		}
 	case stateArray0:
 		printer.state = stateArray1
 	case stateObject0Keyed:
@@ -624,41 +826,121 @@ func (printer *JsonPrinter) putLiteral(literal string, colorliteral string) erro
 	case stateObject1Keyed:
 		printer.state = stateObject1
 	}
+}
 
-	return nil
+// fastScalar returns the emitter if a scalar can be appended right to the
+// buffer, without building a Scalar: in SimpleStyle JSON without colors
+// or hexadecimal numbers.
+func (printer *JsonPrinter) fastScalar() *simpleEmitter {
+	if printer.err != nil || printer.emitter != nil || printer.color || !printer.scalarAllowed() ||
+		printer.json5Option(JSON5HexNumbers) {
+		return nil
+	}
+	e, _ := printer.out().(*simpleEmitter)
+	return e
 }
 
 func (printer *JsonPrinter) PutInt(v int) error {
-	str := strconv.Itoa(v)
-	return printer.putLiteral(str, color(str, colorInt))
+	if e := printer.fastScalar(); e != nil {
+		e.next()
+		printer.buffer.Write(strconv.AppendInt(e.avail(20), int64(v), 10))
+		printer.scalarPut()
+		return nil
+	}
+
+	str := printer.formatInt(int64(v))
+	return printer.putScalar(Scalar{Kind: IntScalar, Literal: str, Value: int64(v)})
 }
 
 func (printer *JsonPrinter) PutInt64(v int64) error {
-	str := strconv.FormatInt(v, 10)
-	return printer.putLiteral(str, color(str, colorInt))
+	if e := printer.fastScalar(); e != nil {
+		e.next()
+		printer.buffer.Write(strconv.AppendInt(e.avail(20), v, 10))
+		printer.scalarPut()
+		return nil
+	}
+
+	str := printer.formatInt(v)
+	return printer.putScalar(Scalar{Kind: IntScalar, Literal: str, Value: v})
 }
 
 func (printer *JsonPrinter) PutFloat(v float64) error {
+	if e := printer.fastScalar(); e != nil {
+		e.next()
+		printer.buffer.Write(strconv.AppendFloat(e.avail(24), v, 'f', -1, 64))
+		printer.scalarPut()
+		return nil
+	}
+
 	str := strconv.FormatFloat(v, 'f', -1, 64)
-	return printer.putLiteral(str, color(str, colorFloat))
+	return printer.putScalar(Scalar{Kind: FloatScalar, Literal: str, Value: v})
 }
 
 func (printer *JsonPrinter) PutFloatFmt(v float64, fmtstr string) error {
 	str := fmt.Sprintf(fmtstr, v)
-	return printer.putLiteral(str, color(str, colorFloat))
+	return printer.putScalar(Scalar{Kind: FloatScalar, Literal: str, Value: v})
 }
 
 func (printer *JsonPrinter) PutString(v string) error {
-	vs, err := json.Marshal(v)
+	if e := printer.fastScalar(); e != nil {
+		e.next()
+		buf, err := printer.appendQuoted(e.avail(len(v)+2), v)
+		if err != nil {
+			printer.err = err
+			return printer.err
+		}
+		printer.buffer.Write(buf)
+		printer.scalarPut()
+		return nil
+	}
+
+	if printer.err != nil {
+		return printer.err
+	}
+
+	s, err := printer.stringScalar(v)
 	if err != nil {
 		return err
 	}
-	str := string(vs)
+	return printer.putScalar(s)
+}
+
+func (printer *JsonPrinter) stringScalar(v string) (Scalar, error) {
+	str, err := printer.quote(v)
+	if err != nil {
+		printer.err = err
+		return Scalar{}, printer.err
+	}
 
-	return printer.putLiteral(str, color(str, colorString))
+	// like the JSON literal, binary formats hold valid UTF-8 only
+	if !utf8.ValidString(v) {
+		v = strings.ToValidUTF8(v, "\uFFFD")
+	}
+
+	return Scalar{Kind: StringScalar, Literal: str, Value: v}, nil
+}
+
+func (printer *JsonPrinter) PutBool(v bool) error {
+	if e := printer.fastScalar(); e != nil {
+		e.next()
+		printer.buffer.Write(strconv.AppendBool(e.avail(5), v))
+		printer.scalarPut()
+		return nil
+	}
+
+	str := strconv.FormatBool(v)
+	return printer.putScalar(Scalar{Kind: BoolScalar, Literal: str, Value: v})
+}
+
+func (printer *JsonPrinter) PutNull() error {
+	return printer.putScalar(Scalar{Kind: NullScalar, Literal: "null", Value: nil})
 }
 
 func (printer *JsonPrinter) PutKey(v string) error {
+	if printer.err != nil {
+		return printer.err
+	}
+
 	switch printer.state {
 	case stateObject0: // OK
 	case stateObject1: // OK
@@ -667,15 +949,11 @@ func (printer *JsonPrinter) PutKey(v string) error {
 		return printer.err
 	}
 
-	vs, err := json.Marshal(v)
-	if err != nil {
-		return err
+	if err := printer.out().Key(v); err != nil {
+		printer.err = err
+		return printer.err
 	}
 
-	vss := string(vs)
-
-	printer.curKey = vss
-
 	if printer.state == stateObject0 {
 		printer.state = stateObject0Keyed
 	} else {
@@ -2,7 +2,11 @@
 
 package projson
 
-import "testing"
+import (
+	"bytes"
+	"io"
+	"testing"
+)
 
 func TestInt(t *testing.T) {
 	var err error
@@ -426,6 +430,9 @@ func TestSetStyle(t *testing.T) {
 	if err == nil {
 		t.Error("SetStyle should return error after putting items")
 	}
+	if _, actual := jp.String(); actual != err {
+		t.Errorf("error of SetStyle is not sticky\nexpected: %v\nactual: %v", err, actual)
+	}
 }
 
 func TestArraySimpleSmartStyle(t *testing.T) {
@@ -515,8 +522,8 @@ func TestObjectSimpleSmartStyle(t *testing.T) {
 
 	expected := `{"key": "val",
  "k": "v",
- "k": [
-  1, 2]}`
+ "k": [1,
+  2]}`
 	actual, _ := jp.String()
 
 	if expected != actual {
@@ -616,3 +623,122 @@ func TestPutFloatFmt(t *testing.T) {
 		t.Errorf("expected: %v\nactual: %v", expected, actual)
 	}
 }
+
+func TestOutput(t *testing.T) {
+	var _ io.WriterTo = NewPrinter()
+
+	jp := NewPrinter()
+	jp.SetFinalNewline(true)
+	jp.PutArray([]interface{}{1, 2})
+
+	expected := "[1,2]\n"
+	if actual, err := jp.Bytes(); err != nil || expected != string(actual) {
+		t.Errorf("expected: %q\nactual: %q (%v)", expected, actual, err)
+	}
+	if actual, err := jp.String(); err != nil || expected != actual {
+		t.Errorf("expected: %q\nactual: %q (%v)", expected, actual, err)
+	}
+
+	var out bytes.Buffer
+	if n, err := jp.WriteTo(&out); err != nil || n != int64(len(expected)) || expected != out.String() {
+		t.Errorf("expected: %q\nactual: %q %v (%v)", expected, out.String(), n, err)
+	}
+
+	// the output written is removed
+	jp.BeginObject()
+	if _, err := jp.WriteTo(&out); err == nil {
+		t.Errorf("expected: error on unfinished output\nactual: nil")
+	}
+	jp.FinishObject()
+	jp.WriteTo(&out)
+
+	expected = "[1,2]\n{}\n"
+	if expected != out.String() {
+		t.Errorf("expected: %q\nactual: %q", expected, out.String())
+	}
+
+	jp = NewPrinter()
+	jp.BeginArray()
+	if err := jp.SetFinalNewline(true); err == nil {
+		t.Error("SetFinalNewline should return error after putting items")
+	}
+
+	// no newline in binary formats
+	jp = NewPrinter()
+	jp.SetFormat(CBORFormat)
+	jp.SetFinalNewline(true)
+	jp.PutInt(1)
+	if actual, _ := jp.Bytes(); !bytes.Equal(actual, []byte{0x01}) {
+		t.Errorf("expected: [1]\nactual: %v", actual)
+	}
+}
+
+func TestSimpleStyleAllocs(t *testing.T) {
+	jp := NewPrinter()
+	jp.buffer.Grow(1 << 20)
+	jp.BeginArray()
+
+	allocs := testing.AllocsPerRun(1000, func() {
+		jp.PutInt(-1234567)
+		jp.PutFloat(3.25)
+		jp.PutString("text with \"quotes\" and <tags>")
+		jp.PutBool(true)
+		jp.PutNull()
+	})
+	if allocs != 0 {
+		t.Errorf("expected: 0 allocations\nactual: %v", allocs)
+	}
+
+	jp.BeginObject()
+	allocs = testing.AllocsPerRun(1000, func() {
+		jp.PutKey("key")
+		jp.PutInt(1)
+	})
+	if allocs != 0 {
+		t.Errorf("expected: 0 allocations\nactual: %v", allocs)
+	}
+}
+
+// Each op puts one element of a large array, or one member of a large
+// object, in SimpleStyle.
+
+func BenchmarkSimpleArrayInt(b *testing.B) {
+	jp := NewPrinter()
+	jp.BeginArray()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		jp.PutInt(i)
+	}
+	jp.FinishArray()
+}
+
+func BenchmarkSimpleArrayFloat(b *testing.B) {
+	jp := NewPrinter()
+	jp.BeginArray()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		jp.PutFloat(float64(i) / 8)
+	}
+	jp.FinishArray()
+}
+
+func BenchmarkSimpleArrayString(b *testing.B) {
+	jp := NewPrinter()
+	jp.BeginArray()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		jp.PutString("a string of some length, with \"quotes\"")
+	}
+	jp.FinishArray()
+}
+
+func BenchmarkSimpleObject(b *testing.B) {
+	jp := NewPrinter()
+	jp.BeginObject()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		jp.PutKey("key")
+		jp.PutInt(i)
+	}
+	jp.FinishObject()
+}
@@ -0,0 +1,7 @@
+//go:build race
+
+package projson
+
+// raceEnabled is set under the race detector, which makes sync.Pool drop
+// items at random.
+const raceEnabled = true
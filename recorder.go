@@ -0,0 +1,140 @@
+package projson
+
+// Recorder is an emitter recording events, to replay them into printers
+// with other settings:
+//
+//	recorder := projson.NewRecorder()
+//	printer.SetEmitter(projson.Tee(printer.AsEmitter(), recorder))
+//	...
+//	recorder.Replay(terminalPrinter)
+type Recorder struct {
+	ops     []recordOp
+	counts  []int    // of BeginArray and BeginObject events
+	keys    []string // and comments
+	scalars []Scalar
+}
+
+type recordOp byte
+
+const (
+	opBeginArray recordOp = iota
+	opEndArray
+	opBeginObject
+	opEndObject
+	opKey
+	opScalar
+	opComment
+)
+
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// Len returns the number of recorded events.
+func (r *Recorder) Len() int {
+	return len(r.ops)
+}
+
+func (r *Recorder) Reset() {
+	r.ops = r.ops[:0]
+	r.counts = r.counts[:0]
+	r.keys = r.keys[:0]
+	r.scalars = r.scalars[:0]
+}
+
+func (r *Recorder) BeginArray(count int) error {
+	r.ops = append(r.ops, opBeginArray)
+	r.counts = append(r.counts, count)
+	return nil
+}
+
+func (r *Recorder) EndArray() error {
+	r.ops = append(r.ops, opEndArray)
+	return nil
+}
+
+func (r *Recorder) BeginObject(count int) error {
+	r.ops = append(r.ops, opBeginObject)
+	r.counts = append(r.counts, count)
+	return nil
+}
+
+func (r *Recorder) EndObject() error {
+	r.ops = append(r.ops, opEndObject)
+	return nil
+}
+
+func (r *Recorder) Key(key string) error {
+	r.ops = append(r.ops, opKey)
+	r.keys = append(r.keys, key)
+	return nil
+}
+
+func (r *Recorder) Scalar(s Scalar) error {
+	r.ops = append(r.ops, opScalar)
+	r.scalars = append(r.scalars, s)
+	return nil
+}
+
+func (r *Recorder) Comment(text string) error {
+	r.ops = append(r.ops, opComment)
+	r.keys = append(r.keys, text)
+	return nil
+}
+
+// Replay puts the recorded events to printer, as they were put to the
+// printer they were recorded from.
+func (r *Recorder) Replay(printer *JsonPrinter) error {
+	var nc, nk, ns int
+	for _, op := range r.ops {
+		switch op {
+		case opBeginArray:
+			printer.beginArray(r.counts[nc])
+			nc++
+		case opEndArray:
+			printer.FinishArray()
+		case opBeginObject:
+			printer.beginObject(r.counts[nc])
+			nc++
+		case opEndObject:
+			printer.FinishObject()
+		case opKey:
+			printer.PutKey(r.keys[nk])
+			nk++
+		case opScalar:
+			if s, err := printer.replayedScalar(r.scalars[ns]); err == nil {
+				printer.putScalar(s)
+			}
+			ns++
+		case opComment:
+			printer.PutComment(r.keys[nk])
+			nk++
+		}
+
+		if printer.err != nil {
+			return printer.err
+		}
+	}
+	return nil
+}
+
+// replayedScalar returns s with the literal of strings and integers made
+// again from its value, as the printer it was recorded from may quote,
+// escape and format them otherwise.
+func (printer *JsonPrinter) replayedScalar(s Scalar) (Scalar, error) {
+	colorcode, b := s.color, s.bytes
+	switch v := s.Value.(type) {
+	case string:
+		var err error
+		if s, err = printer.stringScalar(v); err != nil {
+			return s, err
+		}
+		s.bytes = b
+	case int64:
+		s.Literal = printer.formatInt(v)
+	case uint64:
+		s.Literal = printer.formatUint(v)
+	}
+	s.color = colorcode
+	return s, nil
+}
@@ -0,0 +1,116 @@
+package projson
+
+import "testing"
+
+func TestRecorderReplay(t *testing.T) {
+	recorder := NewRecorder()
+	jp := NewPrinter()
+	jp.SetEmitter(Tee(jp.AsEmitter(), recorder))
+	jp.Obj().
+		Key("a").Arr().Int(1).FloatFmt(2.5, "%.3f").Str("x").End().
+		Key("b").Object(map[string]interface{}{"c": 3}).
+		End()
+
+	if actual, err := jp.String(); err != nil || actual != `{"a":[1,2.500,"x"],"b":{"c":3}}` {
+		t.Errorf("expected: %v\nactual: %v (%v)", `{"a":[1,2.500,"x"],"b":{"c":3}}`, actual, err)
+	}
+	if recorder.Len() != 13 {
+		t.Errorf("expected: 13 events\nactual: %v", recorder.Len())
+	}
+
+	compact := NewPrinter()
+	if err := recorder.Replay(compact); err != nil {
+		t.Fatal(err)
+	}
+	expected, _ := jp.String()
+	if actual, _ := compact.String(); expected != actual {
+		t.Errorf("expected: %v\nactual: %v", expected, actual)
+	}
+
+	pretty := NewPrinter()
+	pretty.SetStyle(PrettyStyle)
+	pretty.SetTermWidth(30)
+	pretty.SetColor(true)
+	if err := recorder.Replay(pretty); err != nil {
+		t.Fatal(err)
+	}
+	expected = "{\n  " + color(`"a"`, colorKey) + ": [" + color("1", colorInt) + ", " + color("2.500", colorFloat) + ", " + color(`"x"`, colorString) + "],\n" +
+		"  " + color(`"b"`, colorKey) + ": {" + color(`"c"`, colorKey) + ": " + color("3", colorInt) + "}\n}"
+	if actual, _ := pretty.String(); expected != actual {
+		t.Errorf("expected: %v\nactual: %v", expected, actual)
+	}
+
+	// counts known in advance are kept
+	cbor := NewPrinter()
+	cbor.SetFormat(CBORFormat)
+	recorder.Replay(cbor)
+	if actual, _ := cbor.String(); actual[len(actual)-5:] != "\xa1\x61c\x03\xff" {
+		t.Errorf("expected definite-length map, actual: %x", actual)
+	}
+}
+
+func TestRecorderReplaySettings(t *testing.T) {
+	recorder := NewRecorder()
+	jp := NewPrinter()
+	jp.SetFormat(JSON5Format)
+	jp.SetJSON5Options(JSON5UnquotedKeys | JSON5SingleQuotes | JSON5HexNumbers)
+	jp.SetEmitter(Tee(jp.AsEmitter(), recorder))
+	jp.BeginObject()
+	jp.PutKey("a")
+	jp.PutString("x<y")
+	jp.PutKey("n")
+	jp.PutInt(255)
+	jp.PutKey("f")
+	jp.PutFloatFmt(0.5, "%.2f")
+	jp.PutKey("c")
+	jp.PutValue(struct {
+		L string `projson:"l,color=yellow"`
+	}{"it's"})
+	jp.FinishObject()
+
+	if actual, err := jp.String(); err != nil || actual != `{a:'x\u003cy',n:0xff,f:0.50,c:{l:'it\'s'}}` {
+		t.Errorf("expected: %v\nactual: %v (%v)", `{a:'x\u003cy',n:0xff,f:0.50,c:{l:'it\'s'}}`, actual, err)
+	}
+
+	pretty := NewPrinter()
+	pretty.SetStyle(PrettyStyle)
+	pretty.SetEscapeHTML(false)
+	if err := recorder.Replay(pretty); err != nil {
+		t.Fatal(err)
+	}
+	expected := "{\"a\": \"x<y\", \"n\": 255, \"f\": 0.50, \"c\": {\"l\": \"it's\"}}"
+	if actual, err := pretty.String(); err != nil || expected != actual {
+		t.Errorf("expected: %v\nactual: %v (%v)", expected, actual, err)
+	}
+
+	// with the color override kept
+	colored := NewPrinter()
+	colored.SetColor(true)
+	recorder.Replay(colored)
+	expected = "{" + color(`"a"`, colorKey) + ":" + color(`"x\u003cy"`, colorString) + "," +
+		color(`"n"`, colorKey) + ":" + color("255", colorInt) + "," +
+		color(`"f"`, colorKey) + ":" + color("0.50", colorFloat) + "," +
+		color(`"c"`, colorKey) + ":{" + color(`"l"`, colorKey) + ":" + color(`"it's"`, colorYellow) + "}}"
+	if actual, err := colored.String(); err != nil || expected != actual {
+		t.Errorf("expected: %v\nactual: %v (%v)", expected, actual, err)
+	}
+}
+
+func TestRecorderReplayError(t *testing.T) {
+	recorder := NewRecorder()
+	jp := NewPrinter()
+	jp.SetEmitter(recorder)
+	jp.Arr().Int(1).End()
+
+	// TOML cannot represent a top-level array
+	toml := NewPrinter()
+	toml.SetFormat(TOMLFormat)
+	if err := recorder.Replay(toml); err == nil {
+		t.Errorf("expected an error replaying an array into TOML")
+	}
+
+	recorder.Reset()
+	if recorder.Len() != 0 {
+		t.Errorf("expected: 0 events\nactual: %v", recorder.Len())
+	}
+}
@@ -0,0 +1,497 @@
+package projson
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Schema is a JSON Schema (draft 2020-12) values are checked against as
+// they are put, with these keywords: type, enum, properties, required,
+// additionalProperties, items, minimum, maximum, exclusiveMinimum,
+// exclusiveMaximum, minLength, maxLength, minItems, maxItems and pattern.
+// Other keywords are ignored. Patterns are Go regular expressions.
+//
+// A violation is reported as soon as what is put makes the document
+// invalid: a value of a wrong type when it is put, and a missing required
+// property when its object is finished.
+type Schema struct {
+	never      bool // the false schema
+	types      []string
+	enum       []interface{}
+	properties map[string]*Schema
+	required   []string
+	additional *Schema // of properties not in properties
+	items      *Schema
+
+	minimum          *float64
+	maximum          *float64
+	exclusiveMinimum *float64
+	exclusiveMaximum *float64
+	minLength        int // -1 if not given, like maxLength, minItems and maxItems
+	maxLength        int
+	minItems         int
+	maxItems         int
+	pattern          *regexp.Regexp
+}
+
+// SchemaError is a violation of a schema, at a JSON path such as
+// $.users[2].name.
+type SchemaError struct {
+	Path    string
+	Message string
+}
+
+func (e *SchemaError) Error() string {
+	return "Schema violation at " + e.Path + ": " + e.Message
+}
+
+var schemaTypes = map[string]bool{
+	"object": true, "array": true, "string": true, "number": true,
+	"integer": true, "boolean": true, "null": true,
+}
+
+func ParseSchema(src []byte) (*Schema, error) {
+	var v interface{}
+	if err := json.Unmarshal(src, &v); err != nil {
+		return nil, err
+	}
+	return parseSchema(v, "#")
+}
+
+// parseSchema builds the schema v at the JSON pointer ptr.
+func parseSchema(v interface{}, ptr string) (*Schema, error) {
+	invalid := func(keyword string, msg string) error {
+		return errors.New("Invalid schema at " + ptr + "/" + keyword + ": " + msg)
+	}
+
+	schema := &Schema{minLength: -1, maxLength: -1, minItems: -1, maxItems: -1}
+	if b, ok := v.(bool); ok {
+		schema.never = !b
+		return schema, nil
+	}
+
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("Invalid schema at " + ptr + ": not an object or a boolean")
+	}
+
+	for keyword, value := range m {
+		var err error
+		switch keyword {
+		case "type":
+			switch value := value.(type) {
+			case string:
+				schema.types = []string{value}
+			case []interface{}:
+				for _, t := range value {
+					if t, ok := t.(string); ok {
+						schema.types = append(schema.types, t)
+					} else {
+						return nil, invalid(keyword, "not a string")
+					}
+				}
+			default:
+				return nil, invalid(keyword, "not a string or an array")
+			}
+			for _, t := range schema.types {
+				if !schemaTypes[t] {
+					return nil, invalid(keyword, "unknown type "+t)
+				}
+			}
+		case "enum":
+			enum, ok := value.([]interface{})
+			if !ok {
+				return nil, invalid(keyword, "not an array")
+			}
+			schema.enum = enum
+		case "properties":
+			properties, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, invalid(keyword, "not an object")
+			}
+			schema.properties = map[string]*Schema{}
+			for key, property := range properties {
+				if schema.properties[key], err = parseSchema(property, ptr+"/properties/"+key); err != nil {
+					return nil, err
+				}
+			}
+		case "required":
+			required, ok := value.([]interface{})
+			if !ok {
+				return nil, invalid(keyword, "not an array")
+			}
+			for _, key := range required {
+				if key, ok := key.(string); ok {
+					schema.required = append(schema.required, key)
+				} else {
+					return nil, invalid(keyword, "not a string")
+				}
+			}
+		case "additionalProperties":
+			schema.additional, err = parseSchema(value, ptr+"/"+keyword)
+		case "items":
+			schema.items, err = parseSchema(value, ptr+"/"+keyword)
+		case "minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum":
+			bound, ok := value.(float64)
+			if !ok {
+				return nil, invalid(keyword, "not a number")
+			}
+			switch keyword {
+			case "minimum":
+				schema.minimum = &bound
+			case "maximum":
+				schema.maximum = &bound
+			case "exclusiveMinimum":
+				schema.exclusiveMinimum = &bound
+			case "exclusiveMaximum":
+				schema.exclusiveMaximum = &bound
+			}
+		case "minLength", "maxLength", "minItems", "maxItems":
+			n, ok := value.(float64)
+			if !ok || n < 0 || n != float64(int(n)) {
+				return nil, invalid(keyword, "not a non-negative integer")
+			}
+			switch keyword {
+			case "minLength":
+				schema.minLength = int(n)
+			case "maxLength":
+				schema.maxLength = int(n)
+			case "minItems":
+				schema.minItems = int(n)
+			case "maxItems":
+				schema.maxItems = int(n)
+			}
+		case "pattern":
+			pattern, ok := value.(string)
+			if !ok {
+				return nil, invalid(keyword, "not a string")
+			}
+			if schema.pattern, err = regexp.Compile(pattern); err != nil {
+				return nil, invalid(keyword, err.Error())
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return schema, nil
+}
+
+func (printer *JsonPrinter) SetSchema(schema *Schema) error {
+	if printer.err != nil {
+		return printer.err
+	}
+
+	if printer.state != stateInit {
+		printer.err = errors.New("Schema cannot changed after putting some items")
+		return printer.err
+	}
+
+	if schema == nil {
+		printer.validator = nil
+	} else {
+		printer.validator = &schemaValidator{root: schema}
+	}
+	return nil
+}
+
+type schemaFrame struct {
+	schema *Schema // nil for any value
+	object bool
+	key    string          // of the current member
+	member *Schema         // of the current member
+	count  int             // number of members so far
+	keys   map[string]bool // put, if some are required
+	value  interface{}     // the value built, if it is checked against an enum
+}
+
+// schemaValidator checks events against a schema before passing them on
+// to the next emitter.
+type schemaValidator struct {
+	root  *Schema
+	stack []*schemaFrame
+	next  Emitter
+}
+
+// path returns the JSON path of the current member of the depth outermost
+// open containers.
+func (v *schemaValidator) path(depth int) string {
+	path := "$"
+	for _, frame := range v.stack[:depth] {
+		switch {
+		case !frame.object:
+			path += "[" + strconv.Itoa(frame.count-1) + "]"
+		case isPathName(frame.key):
+			path += "." + frame.key
+		default:
+			path += "[" + strconv.Quote(frame.key) + "]"
+		}
+	}
+	return path
+}
+
+func isPathName(s string) bool {
+	for i, c := range s {
+		if !(c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || i > 0 && '0' <= c && c <= '9') {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func (v *schemaValidator) fail(depth int, msg string) error {
+	return &SchemaError{Path: v.path(depth), Message: msg}
+}
+
+// enter returns the schema of a value put next, after checking it may be
+// put there.
+func (v *schemaValidator) enter() (*Schema, error) {
+	if len(v.stack) == 0 {
+		return v.root, nil
+	}
+
+	top := v.stack[len(v.stack)-1]
+	if top.object {
+		return top.member, nil
+	}
+
+	top.count++
+	if top.schema == nil {
+		return nil, nil
+	}
+	if top.schema.maxItems >= 0 && top.count > top.schema.maxItems {
+		return nil, v.fail(len(v.stack)-1, "more than "+strconv.Itoa(top.schema.maxItems)+" items")
+	}
+	return top.schema.items, nil
+}
+
+// checkType checks that a value of type typ may be put for schema.
+// integral is whether the value is an integer.
+func (v *schemaValidator) checkType(schema *Schema, typ string, integral bool) error {
+	if schema == nil {
+		return nil
+	}
+	if schema.never {
+		return v.fail(len(v.stack), "value not allowed")
+	}
+	if len(schema.types) == 0 {
+		return nil
+	}
+
+	for _, t := range schema.types {
+		if t == typ || (t == "number" && typ == "integer") || (t == "integer" && integral) {
+			return nil
+		}
+	}
+	return v.fail(len(v.stack), "expected "+strings.Join(schema.types, " or ")+", got "+typ)
+}
+
+// add adds value to the container being built, if any.
+func (v *schemaValidator) add(value interface{}) {
+	if len(v.stack) == 0 {
+		return
+	}
+
+	top := v.stack[len(v.stack)-1]
+	switch container := top.value.(type) {
+	case []interface{}:
+		top.value = append(container, value)
+	case map[string]interface{}:
+		container[top.key] = value
+	}
+}
+
+func (v *schemaValidator) checkEnum(schema *Schema, value interface{}, depth int) error {
+	if schema == nil || schema.enum == nil {
+		return nil
+	}
+	for _, e := range schema.enum {
+		if reflect.DeepEqual(e, value) {
+			return nil
+		}
+	}
+	return v.fail(depth, "value not in enum")
+}
+
+func (v *schemaValidator) begin(object bool) error {
+	schema, err := v.enter()
+	if err != nil {
+		return err
+	}
+
+	typ := "array"
+	if object {
+		typ = "object"
+	}
+	if err := v.checkType(schema, typ, false); err != nil {
+		return err
+	}
+
+	frame := &schemaFrame{schema: schema, object: object}
+	if schema != nil && len(schema.required) > 0 {
+		frame.keys = map[string]bool{}
+	}
+	if (schema != nil && schema.enum != nil) || (len(v.stack) > 0 && v.stack[len(v.stack)-1].value != nil) {
+		if object {
+			frame.value = map[string]interface{}{}
+		} else {
+			frame.value = []interface{}{}
+		}
+	}
+
+	v.stack = append(v.stack, frame)
+	return nil
+}
+
+func (v *schemaValidator) end() error {
+	depth := len(v.stack) - 1
+	frame := v.stack[depth]
+
+	if schema := frame.schema; schema != nil {
+		if !frame.object && frame.count < schema.minItems {
+			return v.fail(depth, "fewer than "+strconv.Itoa(schema.minItems)+" items")
+		}
+		for _, key := range schema.required {
+			if !frame.keys[key] {
+				return v.fail(depth, "missing required property "+strconv.Quote(key))
+			}
+		}
+		if err := v.checkEnum(schema, frame.value, depth); err != nil {
+			return err
+		}
+	}
+
+	v.stack = v.stack[:depth]
+	v.add(frame.value)
+	return nil
+}
+
+func (v *schemaValidator) BeginArray(count int) error {
+	if err := v.begin(false); err != nil {
+		return err
+	}
+	return v.next.BeginArray(count)
+}
+
+func (v *schemaValidator) EndArray() error {
+	if err := v.end(); err != nil {
+		return err
+	}
+	return v.next.EndArray()
+}
+
+func (v *schemaValidator) BeginObject(count int) error {
+	if err := v.begin(true); err != nil {
+		return err
+	}
+	return v.next.BeginObject(count)
+}
+
+func (v *schemaValidator) EndObject() error {
+	if err := v.end(); err != nil {
+		return err
+	}
+	return v.next.EndObject()
+}
+
+func (v *schemaValidator) Key(key string) error {
+	top := v.stack[len(v.stack)-1]
+	top.key = key
+	top.count++
+	top.member = nil
+
+	if schema := top.schema; schema != nil {
+		if property, ok := schema.properties[key]; ok {
+			top.member = property
+		} else {
+			top.member = schema.additional
+		}
+	}
+	if top.member != nil && top.member.never {
+		return v.fail(len(v.stack), "property not allowed")
+	}
+	if top.keys != nil {
+		top.keys[key] = true
+	}
+
+	return v.next.Key(key)
+}
+
+func (v *schemaValidator) Scalar(s Scalar) error {
+	schema, err := v.enter()
+	if err != nil {
+		return err
+	}
+
+	value := s.Value
+	var typ string
+	var integral bool
+	switch x := s.Value.(type) {
+	case int64:
+		typ, integral, value = "integer", true, float64(x)
+	case uint64:
+		typ, integral, value = "integer", true, float64(x)
+	case float64:
+		typ, integral = "number", x == float64(int64(x))
+	case string:
+		typ = "string"
+	case bool:
+		typ = "boolean"
+	default:
+		typ = "null"
+	}
+
+	if err := v.checkType(schema, typ, integral); err != nil {
+		return err
+	}
+	if err := v.checkEnum(schema, value, len(v.stack)); err != nil {
+		return err
+	}
+	if schema != nil {
+		if err := v.checkScalar(schema, value); err != nil {
+			return err
+		}
+	}
+
+	v.add(value)
+	return v.next.Scalar(s)
+}
+
+// checkScalar checks the bounds of a number and the length and pattern of
+// a string.
+func (v *schemaValidator) checkScalar(schema *Schema, value interface{}) error {
+	format := func(x float64) string {
+		return strconv.FormatFloat(x, 'g', -1, 64)
+	}
+
+	switch x := value.(type) {
+	case float64:
+		switch {
+		case schema.minimum != nil && x < *schema.minimum:
+			return v.fail(len(v.stack), "less than minimum "+format(*schema.minimum))
+		case schema.maximum != nil && x > *schema.maximum:
+			return v.fail(len(v.stack), "greater than maximum "+format(*schema.maximum))
+		case schema.exclusiveMinimum != nil && x <= *schema.exclusiveMinimum:
+			return v.fail(len(v.stack), "not greater than exclusive minimum "+format(*schema.exclusiveMinimum))
+		case schema.exclusiveMaximum != nil && x >= *schema.exclusiveMaximum:
+			return v.fail(len(v.stack), "not less than exclusive maximum "+format(*schema.exclusiveMaximum))
+		}
+	case string:
+		length := utf8.RuneCountInString(x)
+		switch {
+		case schema.minLength >= 0 && length < schema.minLength:
+			return v.fail(len(v.stack), "shorter than "+strconv.Itoa(schema.minLength)+" characters")
+		case schema.maxLength >= 0 && length > schema.maxLength:
+			return v.fail(len(v.stack), "longer than "+strconv.Itoa(schema.maxLength)+" characters")
+		case schema.pattern != nil && !schema.pattern.MatchString(x):
+			return v.fail(len(v.stack), "not matching pattern "+schema.pattern.String())
+		}
+	}
+	return nil
+}
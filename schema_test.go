@@ -0,0 +1,131 @@
+package projson
+
+import (
+	"testing"
+)
+
+const testSchema = `{
+	"type": "object",
+	"properties": {
+		"name": {"type": "string", "minLength": 1, "pattern": "^[a-z]+$"},
+		"age": {"type": "integer", "minimum": 0, "exclusiveMaximum": 200},
+		"role": {"enum": ["admin", "user", null]},
+		"tags": {"type": "array", "items": {"type": "string"}, "maxItems": 2},
+		"point": {"type": "array", "minItems": 2, "enum": [[0, 0], [1, 2]]},
+		"meta": {"type": "object", "additionalProperties": {"type": ["number", "boolean"]}},
+		"first name": {"type": "string", "maxLength": 3}
+	},
+	"required": ["name"],
+	"additionalProperties": false
+}`
+
+func TestSchema(t *testing.T) {
+	schema, err := ParseSchema([]byte(testSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		doc  string
+		path string // of the violation, if any
+	}{
+		{`{"name": "alice", "age": 20, "role": null, "tags": ["a", "b"], "point": [1, 2], "meta": {"x": 1.5, "y": true}}`, ""},
+		{`{"name": "alice", "age": 20.0}`, ""},
+		{`[]`, "$"},
+		{`{"age": 20}`, "$"},
+		{`{"name": ""}`, "$.name"},
+		{`{"name": "Alice"}`, "$.name"},
+		{`{"name": "alice", "age": -1}`, "$.age"},
+		{`{"name": "alice", "age": 200}`, "$.age"},
+		{`{"name": "alice", "age": 2.5}`, "$.age"},
+		{`{"name": "alice", "role": "root"}`, "$.role"},
+		{`{"name": "alice", "tags": ["a", 1]}`, "$.tags[1]"},
+		{`{"name": "alice", "tags": ["a", "b", "c"]}`, "$.tags"},
+		{`{"name": "alice", "point": [1]}`, "$.point"},
+		{`{"name": "alice", "point": [1, 3]}`, "$.point"},
+		{`{"name": "alice", "meta": {"x": "y"}}`, "$.meta.x"},
+		{`{"name": "alice", "first name": "alice"}`, `$["first name"]`},
+		{`{"name": "alice", "other": 1}`, "$.other"},
+	}
+
+	for _, c := range cases {
+		jp := NewPrinter()
+		jp.SetSchema(schema)
+		err := putJSON(jp, c.doc)
+
+		switch e := err.(type) {
+		case nil:
+			if c.path != "" {
+				t.Errorf("%s\nexpected: violation at %v\nactual: nil", c.doc, c.path)
+			}
+		case *SchemaError:
+			if e.Path != c.path {
+				t.Errorf("%s\nexpected: violation at %v\nactual: %v", c.doc, c.path, e)
+			}
+		default:
+			t.Errorf("%s\nunexpected error: %v", c.doc, err)
+		}
+	}
+}
+
+func TestSchemaImmediate(t *testing.T) {
+	schema, _ := ParseSchema([]byte(testSchema))
+
+	jp := NewPrinter()
+	jp.SetSchema(schema)
+	jp.BeginObject()
+	jp.PutKey("name")
+	jp.PutString("alice")
+	if err := jp.PutKey("other"); err == nil {
+		t.Errorf("expected: error at the key\nactual: nil")
+	}
+
+	// nothing invalid is written
+	jp = NewPrinter()
+	recorder := NewRecorder()
+	jp.SetEmitter(recorder)
+	jp.SetSchema(schema)
+	jp.BeginObject()
+	jp.PutKey("age")
+	jp.PutString("old")
+	if recorder.Len() != 2 {
+		t.Errorf("expected: 2 events\nactual: %v", recorder.Len())
+	}
+
+	expected := "Schema violation at $.age: expected integer, got string"
+	if err := jp.Error(); err == nil || err.Error() != expected {
+		t.Errorf("expected: %v\nactual: %v", expected, err)
+	}
+}
+
+func TestParseSchema(t *testing.T) {
+	for _, src := range []string{
+		`true`,
+		`false`,
+		`{"items": false}`,
+		`{"unknown": 1, "type": ["string", "null"]}`,
+	} {
+		if _, err := ParseSchema([]byte(src)); err != nil {
+			t.Errorf("%s\nunexpected error: %v", src, err)
+		}
+	}
+
+	for _, src := range []string{
+		`1`,
+		`{"type": "str"}`,
+		`{"minLength": -1}`,
+		`{"pattern": "("}`,
+		`{"properties": {"a": 1}}`,
+	} {
+		if _, err := ParseSchema([]byte(src)); err == nil {
+			t.Errorf("%s\nexpected: error\nactual: nil", src)
+		}
+	}
+
+	jp := NewPrinter()
+	schema, _ := ParseSchema([]byte(`false`))
+	jp.SetSchema(schema)
+	if err := jp.PutNull(); err == nil {
+		t.Errorf("expected: error\nactual: nil")
+	}
+}
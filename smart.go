@@ -0,0 +1,103 @@
+package projson
+
+// SmartStyle layout model: the output is a sequence of unbreakable atoms (a scalar,
+// a `"key": scalar` member, an opening bracket with its key, a closing
+// bracket). Line breaks are only allowed between atoms. An atom is put on
+// the current line if it fits there together with the comma that directly
+// follows it, otherwise the line is broken and the atom starts at the
+// indentation of its container's nesting level. Closing brackets break
+// the same way. Hence no line is wider than termwid, except for lines
+// holding a single atom that is wider on its own.
+
+func (printer *JsonPrinter) renderSmart(n *node) {
+	printer.smartNode(n, 0, true, false, 0)
+}
+
+// smartNode lays out n, whose atoms break to indentation level. first is
+// whether n is the first member of its container, and reserve is the
+// width of the punctuation that must stay on the line after n.
+func (printer *JsonPrinter) smartNode(n *node, level int, first bool, forceBreak bool, reserve int) {
+	text, colortext := printer.head(n)
+
+	// comments stay on the line of what follows them
+	for i := len(n.comments) - 1; i >= 0; i-- {
+		comment := blockComment(n.comments[i])
+		text = comment + " " + text
+		colortext = printer.paint(comment, colorComment) + " " + colortext
+	}
+
+	if n.kind == nodeScalar || n.empty() {
+		printer.smartAtom(text, colortext, level, first, forceBreak, reserve)
+		return
+	}
+
+	printer.smartAtom(text, colortext, level, first, forceBreak, 0)
+
+	for i, child := range n.children {
+		childReserve := 0
+		if i < len(n.children)-1 {
+			childReserve = 1 // ","
+		}
+
+		// containers in an object start on their own line unless first
+		childForceBreak := n.kind == nodeObject && i > 0 && child.kind != nodeScalar
+
+		printer.smartNode(child, level+1, i == 0, childForceBreak, childReserve)
+	}
+
+	_, closer := n.brackets()
+	comments, colorcomments := "", ""
+	for _, text := range n.trailing {
+		comment := blockComment(text)
+		comments += " " + comment
+		colorcomments += " " + printer.paint(comment, colorComment)
+	}
+
+	width := displayWidth(comments) + len(closer)
+	broken := printer.linepos+width+reserve > printer.termwid && printer.linepos > level+1
+	if broken {
+		printer.smartNewline(level + 1)
+	}
+	if comments != "" && (broken || len(n.children) == 0) {
+		// no space after a line break or an opening bracket
+		comments, colorcomments = comments[1:], colorcomments[1:]
+		width--
+	}
+	if printer.color {
+		printer.buffer.WriteString(colorcomments + printer.paintBracket(closer))
+	} else {
+		printer.buffer.WriteString(comments + closer)
+	}
+	printer.linepos += width
+}
+
+func (printer *JsonPrinter) smartAtom(text string, colortext string, level int, first bool, forceBreak bool, reserve int) {
+	width := displayWidth(text)
+
+	if first {
+		// breaking right after an opening bracket helps only if the
+		// bracket is not already at the indentation column
+		if printer.linepos+width+reserve > printer.termwid && printer.linepos > level {
+			printer.smartNewline(level)
+		}
+	} else if forceBreak || printer.linepos+2+width+reserve > printer.termwid {
+		printer.buffer.WriteString(",")
+		printer.smartNewline(level)
+	} else {
+		printer.buffer.WriteString(", ")
+		printer.linepos += 2
+	}
+
+	if printer.color {
+		printer.buffer.WriteString(colortext)
+	} else {
+		printer.buffer.WriteString(text)
+	}
+	printer.linepos += width
+}
+
+func (printer *JsonPrinter) smartNewline(level int) {
+	printer.buffer.WriteString("\n")
+	printer.buffer.WriteString(indent(" ", level))
+	printer.linepos = level
+}
@@ -0,0 +1,273 @@
+package projson
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files in testdata")
+
+// putJSON replays a JSON document through the procedural API, keeping
+// the order of object members.
+func putJSON(jp *JsonPrinter, src string) error {
+	dec := json.NewDecoder(strings.NewReader(src))
+	dec.UseNumber()
+
+	// for each open container, whether the next token is an object key
+	var wantKey []bool
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return jp.Error()
+		}
+		if err != nil {
+			return err
+		}
+
+		top := len(wantKey) - 1
+		if tok == json.Delim(']') || tok == json.Delim('}') {
+			wantKey = wantKey[:top]
+		} else if top >= 0 && wantKey[top] {
+			jp.PutKey(tok.(string))
+			wantKey[top] = false
+			continue
+		} else if top >= 0 && jp.pathStack[len(jp.pathStack)-1].typ == frameObject {
+			// the value completes a member; a key comes next
+			wantKey[top] = true
+		}
+
+		switch v := tok.(type) {
+		case json.Delim:
+			switch v {
+			case '[':
+				jp.BeginArray()
+				wantKey = append(wantKey, false)
+			case '{':
+				jp.BeginObject()
+				wantKey = append(wantKey, true)
+			case ']':
+				jp.FinishArray()
+			case '}':
+				jp.FinishObject()
+			}
+		case string:
+			jp.PutString(v)
+		case bool:
+			jp.PutBool(v)
+		case nil:
+			jp.PutNull()
+		case json.Number:
+			if i, err := v.Int64(); err == nil {
+				jp.PutInt64(i)
+			} else if f, err := v.Float64(); err == nil {
+				jp.PutFloat(f)
+			} else {
+				return err
+			}
+		default:
+			return fmt.Errorf("unsupported token: %v", tok)
+		}
+	}
+}
+
+func TestSmartGolden(t *testing.T) {
+	cases := []struct {
+		name  string
+		width int
+	}{
+		{"flat_array", 10},
+		{"flat_array", 40},
+		{"nested_arrays", 10},
+		{"nested_arrays", 16},
+		{"objects", 12},
+		{"objects", 30},
+		{"objects", 80},
+		{"records", 24},
+		{"records", 60},
+		{"wide_chars", 14},
+		{"wide_chars", 30},
+		{"long_tokens", 8},
+		{"deep", 6},
+		{"deep", 20},
+	}
+
+	for _, c := range cases {
+		src, err := ioutil.ReadFile(filepath.Join("testdata", "smart", c.name+".json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		jp := NewPrinter()
+		jp.SetStyle(SmartStyle)
+		jp.SetTermWidth(c.width)
+		if err := putJSON(jp, string(src)); err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		actual, err := jp.String()
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+
+		golden := filepath.Join("testdata", "smart", fmt.Sprintf("%s.w%d.golden", c.name, c.width))
+		if *update {
+			if err := ioutil.WriteFile(golden, []byte(actual), 0644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+
+		expected, err := ioutil.ReadFile(golden)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(expected) != actual {
+			t.Errorf("%s (width %d)\nexpected:\n%v\nactual:\n%v", c.name, c.width, string(expected), actual)
+		}
+	}
+}
+
+// randomDoc generates random documents. Atoms (see smart.go) wider
+// than maxAtom are recorded in long.
+type randomDoc struct {
+	rnd     *rand.Rand
+	words   []string
+	long    map[string]bool
+	maxAtom int
+}
+
+func (g *randomDoc) word() string {
+	ws := make([]string, 1+g.rnd.Intn(3))
+	for i := range ws {
+		ws[i] = g.words[g.rnd.Intn(len(g.words))]
+	}
+	return strings.Join(ws, " ")
+}
+
+func (g *randomDoc) atom(key string, literal string) {
+	if key != "" {
+		literal = strconv.Quote(key) + ": " + literal
+	}
+	if displayWidth(literal) > g.maxAtom {
+		g.long[literal] = true
+	}
+}
+
+func (g *randomDoc) put(jp *JsonPrinter, key string, depth int) {
+	k := g.rnd.Intn(10)
+	if depth <= 0 && k >= 6 {
+		k = g.rnd.Intn(6)
+	}
+
+	switch {
+	case k < 2:
+		v := g.rnd.Intn(100000) - 1000
+		g.atom(key, strconv.Itoa(v))
+		jp.PutInt(v)
+	case k < 3:
+		v := float64(g.rnd.Intn(100000)) / 64
+		g.atom(key, strconv.FormatFloat(v, 'f', -1, 64))
+		jp.PutFloat(v)
+	case k < 6:
+		v := g.word()
+		vs, _ := json.Marshal(v)
+		g.atom(key, string(vs))
+		jp.PutString(v)
+	case k < 8:
+		g.atom(key, "[")
+		jp.BeginArray()
+		for n := g.rnd.Intn(8); n > 0; n-- {
+			g.put(jp, "", depth-1)
+		}
+		jp.FinishArray()
+	default:
+		g.atom(key, "{")
+		jp.BeginObject()
+		for n := g.rnd.Intn(6); n > 0; n-- {
+			key := g.words[g.rnd.Intn(len(g.words))]
+			jp.PutKey(key)
+			g.put(jp, key, depth-1)
+		}
+		jp.FinishObject()
+	}
+}
+
+// checkLineWidths lays out random documents in the given style and checks
+// that they are equivalent to SimpleStyle output and that no line is
+// wider than the terminal width, except for lines holding a single atom
+// which is too wide on its own. Atoms up to slack cells narrower than the
+// width must always fit.
+func checkLineWidths(t *testing.T, style int, slack int) {
+	const depth = 5
+
+	g := &randomDoc{
+		words: []string{"a", "bc", "def", "ghij", "日本", "テスト", "\U0001F600", "e\u0301t\u00e9", "long-long-word"},
+		long:  map[string]bool{},
+	}
+
+	for seed := int64(0); seed < 500; seed++ {
+		g.rnd = rand.New(rand.NewSource(seed))
+		width := 20 + g.rnd.Intn(60)
+		g.maxAtom = width - slack
+		g.long = map[string]bool{}
+
+		jp := NewPrinter()
+		jp.SetStyle(style)
+		jp.SetTermWidth(width)
+		g.put(jp, "", depth)
+		actual, err := jp.String()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the same document in SimpleStyle
+		g.rnd = rand.New(rand.NewSource(seed))
+		g.rnd.Intn(60)
+		jp = NewPrinter()
+		g.put(jp, "", depth)
+		simple, _ := jp.String()
+
+		var compacted bytes.Buffer
+		if err := json.Compact(&compacted, []byte(actual)); err != nil {
+			t.Fatalf("invalid JSON at width %d: %v\n%s", width, err, actual)
+		}
+		if compacted.String() != simple {
+			t.Errorf("document changed by layout\nexpected: %v\nactual: %v", simple, compacted.String())
+		}
+
+		for _, line := range strings.Split(actual, "\n") {
+			if displayWidth(line) <= width {
+				continue
+			}
+
+			// allowed only for a line holding a single atom which is wider
+			// than the width on its own
+			single := !strings.Contains(line, ", ")
+			long := false
+			for atom := range g.long {
+				if strings.Contains(line, atom) {
+					long = true
+				}
+			}
+			if !single || !long {
+				t.Errorf("line exceeds width %d:\n%s\nin:\n%s", width, line, actual)
+			}
+		}
+	}
+}
+
+func TestSmartLineWidthProperty(t *testing.T) {
+	// indentation and a run of opening brackets take up to 2*(depth+1)
+	checkLineWidths(t, SmartStyle, 12)
+}
@@ -0,0 +1,149 @@
+package projson
+
+// In table mode, PrettyStyle and HybridStyle put arrays of flat objects
+// with the same keys one object per line, with the members aligned in
+// columns. Numeric columns are right-aligned:
+//
+//	[
+//	  {"id":  1, "name": "alice", "score": 92.5},
+//	  {"id": 20, "name": "bob",   "score":   78}
+//	]
+
+// tableRows returns the members of the objects in n, each in the order of
+// the first object, or nil if n is not an array of at least two
+// non-empty objects of scalars with the same set of keys.
+func (n *node) tableRows() [][]*node {
+	if n.kind != nodeArray || len(n.children) < 2 || n.commented() {
+		return nil
+	}
+
+	var keys []string
+	rows := [][]*node{}
+	for _, record := range n.children {
+		if record.kind != nodeObject || len(record.children) == 0 || record.commented() {
+			return nil
+		}
+
+		members := map[string]*node{}
+		for _, member := range record.children {
+			if member.kind != nodeScalar || members[member.key] != nil {
+				return nil
+			}
+			members[member.key] = member
+		}
+
+		if keys == nil {
+			for _, member := range record.children {
+				keys = append(keys, member.key)
+			}
+		}
+		if len(members) != len(keys) {
+			return nil
+		}
+
+		row := make([]*node, len(keys))
+		for i, key := range keys {
+			if row[i] = members[key]; row[i] == nil {
+				return nil
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+// tableDoc returns the table layout of n, or nil if n is not a table or
+// the table does not fit in the terminal at nesting depth.
+func (printer *JsonPrinter) tableDoc(n *node, depth int) *doc {
+	rows := n.tableRows()
+	if rows == nil {
+		return nil
+	}
+
+	cells := printer.alignedRows(rows, "{", "}", depth)
+	if cells == nil {
+		return nil
+	}
+
+	return groupDoc(
+		textDoc("[", printer.paintBracket("["), printer.color),
+		cells,
+		softLineDoc(),
+		textDoc("]", printer.paintBracket("]"), printer.color))
+}
+
+// alignedRows returns rows of scalars enclosed in opener and closer, one
+// row per line with aligned columns when broken, and all on one line
+// otherwise. It returns nil if the aligned rows do not fit in the
+// terminal at nesting depth.
+func (printer *JsonPrinter) alignedRows(rows [][]*node, opener string, closer string, depth int) *doc {
+	colwid := []int{}
+	numeric := []bool{}
+	for _, row := range rows {
+		for j, v := range row {
+			if j == len(colwid) {
+				colwid = append(colwid, 0)
+				numeric = append(numeric, true)
+			}
+			if w := displayWidth(v.literal); w > colwid[j] {
+				colwid[j] = w
+			}
+			numeric[j] = numeric[j] && v.isNumber()
+		}
+	}
+
+	rowIndent := (depth + 1) * prettyIndent
+	aligned := []*doc{softLineDoc()}
+	flat := []*doc{}
+	for i, row := range rows {
+		if i > 0 {
+			aligned = append(aligned, lineDoc())
+			flat = append(flat, lineDoc())
+		}
+
+		text, colortext, padded, colorpadded := opener, opener, opener, opener
+		trail := "" // padding of a left-aligned cell, put after its comma
+		for j, v := range row {
+			keytext, colorkeytext := "", ""
+			if v.key != "" {
+				keytext, colorkeytext = v.key+": ", printer.paint(v.key, colorKey)+": "
+			}
+
+			sep, padsep := "", ""
+			if j > 0 {
+				sep, padsep = ", ", ","+trail+" "
+			}
+			text += sep + keytext + v.literal
+			colortext += sep + colorkeytext + v.colorliteral
+
+			pad := indent(" ", colwid[j]-displayWidth(v.literal))
+			if numeric[j] {
+				padded += padsep + keytext + pad + v.literal
+				colorpadded += padsep + colorkeytext + pad + v.colorliteral
+				trail = ""
+			} else {
+				padded += padsep + keytext + v.literal
+				colorpadded += padsep + colorkeytext + v.colorliteral
+				trail = pad
+			}
+		}
+		text, colortext = text+closer, colortext+closer
+		padded, colorpadded = padded+closer, colorpadded+closer
+		if i < len(rows)-1 {
+			text, colortext = text+",", colortext+","
+			padded, colorpadded = padded+",", colorpadded+","
+		} else if printer.json5Option(JSON5TrailingCommas) {
+			padded, colorpadded = padded+",", colorpadded+","
+		}
+
+		if rowIndent+displayWidth(padded) > printer.termwid {
+			return nil
+		}
+
+		aligned = append(aligned, textDoc(padded, colorpadded, printer.color))
+		flat = append(flat, textDoc(text, colortext, printer.color))
+	}
+
+	return nestDoc(prettyIndent, ifBreakDoc(concatDoc(aligned...), concatDoc(flat...)))
+}
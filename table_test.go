@@ -0,0 +1,117 @@
+package projson
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+const tableTestDoc = `{"users": [{"id": 1, "name": "alice", "score": 92.5}, {"name": "bob", "score": 78, "id": 20}, {"id": 300, "name": "carol", "score": -1}], "other": [{"a": 1}, {"b": 2}]}`
+
+func TestTableMode(t *testing.T) {
+	cases := []struct {
+		style    int
+		width    int
+		expected string
+	}{
+		{PrettyStyle, 60, `{
+  "users": [
+    {"id":   1, "name": "alice", "score": 92.5},
+    {"id":  20, "name": "bob",   "score":   78},
+    {"id": 300, "name": "carol", "score":   -1}
+  ],
+  "other": [{"a": 1}, {"b": 2}]
+}`},
+		{HybridStyle, 60, `{
+  "users": [
+    {"id":   1, "name": "alice", "score": 92.5},
+    {"id":  20, "name": "bob",   "score":   78},
+    {"id": 300, "name": "carol", "score":   -1}
+  ],
+  "other": [
+    {
+      "a": 1
+    },
+    {
+      "b": 2
+    }
+  ]
+}`},
+		// rows do not fit: normal layout
+		{PrettyStyle, 46, `{
+  "users": [
+    {"id": 1, "name": "alice", "score": 92.5},
+    {"name": "bob", "score": 78, "id": 20},
+    {"id": 300, "name": "carol", "score": -1}
+  ],
+  "other": [{"a": 1}, {"b": 2}]
+}`},
+	}
+
+	for _, c := range cases {
+		jp := NewPrinter()
+		jp.SetStyle(c.style)
+		jp.SetTermWidth(c.width)
+		jp.SetTableMode(true)
+		if err := putJSON(jp, tableTestDoc); err != nil {
+			t.Fatal(err)
+		}
+
+		actual, _ := jp.String()
+		if c.expected != actual {
+			t.Errorf("style %d, width %d\nexpected: %v\nactual: %v", c.style, c.width, c.expected, actual)
+		}
+		if !json.Valid([]byte(actual)) {
+			t.Errorf("invalid JSON: %v", actual)
+		}
+	}
+}
+
+func TestTableRows(t *testing.T) {
+	cases := []struct {
+		src   string
+		table bool
+	}{
+		{`[{"a": 1, "b": 2}, {"b": 3, "a": 4}]`, true},
+		{`[{"a": 1}]`, false},                           // one row
+		{`[{"a": 1, "b": 2}, {"a": 3}]`, false},         // different keys
+		{`[{"a": 1, "b": 2}, {"a": 3, "c": 4}]`, false}, // different keys
+		{`[{"a": 1, "a": 2}, {"a": 3, "b": 4}]`, false}, // duplicate key
+		{`[{"a": [1]}, {"a": [2]}]`, false},             // not flat
+		{`[{}, {}]`, false},
+		{`[{"a": 1}, 2]`, false},
+	}
+
+	for _, c := range cases {
+		// keep an enclosing array open, so that the tree is not rendered
+		jp := NewPrinter()
+		jp.SetStyle(PrettyStyle)
+		jp.BeginArray()
+		if err := putJSON(jp, c.src); err != nil {
+			t.Fatal(err)
+		}
+		n := jp.out().(*treeEmitter).stack[0].children[0]
+
+		if actual := n.tableRows() != nil; actual != c.table {
+			t.Errorf("%s\nexpected: %v\nactual: %v", c.src, c.table, actual)
+		}
+	}
+}
+
+func TestTableModeColor(t *testing.T) {
+	jp := NewPrinter()
+	jp.SetStyle(PrettyStyle)
+	jp.SetTermWidth(20)
+	jp.SetTableMode(true)
+	jp.SetColor(true)
+
+	jp.BeginArray()
+	jp.PutObject(map[string]interface{}{"k": "ab"})
+	jp.PutObject(map[string]interface{}{"k": "c"})
+	jp.FinishArray()
+
+	expected := "[\n  {" + color(`"k"`, colorKey) + ": " + color(`"ab"`, colorString) + "},\n" +
+		"  {" + color(`"k"`, colorKey) + ": " + color(`"c"`, colorString) + "}\n]"
+	if actual, _ := jp.String(); expected != actual {
+		t.Errorf("expected: %v\nactual: %v", expected, actual)
+	}
+}
@@ -0,0 +1,179 @@
+package projson
+
+import (
+	"errors"
+	"strings"
+)
+
+// TOMLFormat puts the top-level object as a TOML document. Objects are
+// written as tables and arrays of objects as arrays of tables; objects in
+// other arrays are written as inline tables:
+//
+//	title = "example"
+//	points = [[1, 2], { x = 3, y = 4 }]
+//
+//	[owner]
+//	name = "alice"
+//
+//	[[products]]
+//	name = "hammer"
+//
+// Within each table, key/value pairs are put before sub-tables, as TOML
+// requires.
+
+func (printer *JsonPrinter) renderTOML(n *node) error {
+	if n.kind != nodeObject {
+		return errors.New("TOML document must be an object")
+	}
+	if printer.docs > 0 {
+		return errors.New("TOML document must be a single object")
+	}
+
+	text, err := printer.tomlTable(n, nil, "")
+	if err != nil {
+		return err
+	}
+	printer.buffer.WriteString(text)
+	return nil
+}
+
+// isTableArray reports whether n is written as an array of tables.
+func (n *node) isTableArray() bool {
+	if n.kind != nodeArray || len(n.children) == 0 {
+		return false
+	}
+	for _, child := range n.children {
+		if child.kind != nodeObject {
+			return false
+		}
+	}
+	return true
+}
+
+// tomlTable returns the table n at path, headed with header.
+func (printer *JsonPrinter) tomlTable(n *node, path []string, header string) (string, error) {
+	text := ""
+	tables := []*node{}
+	for _, child := range n.children {
+		if (child.kind == nodeObject && len(child.children) > 0) || child.isTableArray() {
+			tables = append(tables, child)
+			continue
+		}
+
+		value, err := printer.tomlValue(child)
+		if err != nil {
+			return "", err
+		}
+		text += printer.tomlKey(child.name) + " = " + value + "\n"
+	}
+
+	// the header of a table holding only tables is implied by theirs
+	if text != "" || len(tables) == 0 || strings.HasPrefix(header, "[[") {
+		text = header + text
+	}
+
+	for _, child := range tables {
+		childPath := append(path[:len(path):len(path)], printer.tomlKey(child.name))
+		dotted := strings.Join(childPath, ".")
+
+		elems, opener, closer := []*node{child}, "[", "]"
+		if child.kind == nodeArray {
+			elems, opener, closer = child.children, "[[", "]]"
+		}
+		for _, elem := range elems {
+			table, err := printer.tomlTable(elem, childPath, opener+dotted+closer+"\n")
+			if err != nil {
+				return "", err
+			}
+			if text != "" {
+				text += "\n"
+			}
+			text += table
+		}
+	}
+
+	return text, nil
+}
+
+// tomlKey returns key as a bare or quoted TOML key.
+func (printer *JsonPrinter) tomlKey(key string) string {
+	if !tomlBare(key) {
+		key, _ = printer.quoteLong(key)
+	}
+	return printer.colorize(key, colorKey)
+}
+
+func tomlBare(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !(r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// tomlValue returns n as an inline TOML value.
+func (printer *JsonPrinter) tomlValue(n *node) (string, error) {
+	switch n.kind {
+	case nodeArray:
+		text := "["
+		for i, child := range n.children {
+			if i > 0 {
+				text += ", "
+				if printer.tomlstrict && (child.kind != n.children[0].kind || child.scalar != n.children[0].scalar) {
+					return "", errors.New("Array of mixed types cannot be represented in strict TOML")
+				}
+			}
+			value, err := printer.tomlValue(child)
+			if err != nil {
+				return "", err
+			}
+			text += value
+		}
+		return text + "]", nil
+	case nodeObject:
+		if len(n.children) == 0 {
+			return "{}", nil
+		}
+		members := []string{}
+		for _, child := range n.children {
+			value, err := printer.tomlValue(child)
+			if err != nil {
+				return "", err
+			}
+			members = append(members, printer.tomlKey(child.name)+" = "+value)
+		}
+		return "{ " + strings.Join(members, ", ") + " }", nil
+	}
+
+	if n.scalar == NullScalar {
+		return "", errors.New("null cannot be represented in TOML")
+	}
+	if _, ok := n.value.(uint64); ok {
+		return "", errors.New("Integer " + n.literal + " cannot be represented in TOML")
+	}
+
+	text := n.literal
+	if v, ok := n.value.(string); ok && n.scalar == StringScalar {
+		// TOML has no surrogate escapes
+		text, _ = printer.quoteLong(v)
+	}
+	if n.scalar == FloatScalar {
+		text = strings.TrimSpace(text)
+		switch {
+		case text == "NaN":
+			text = "nan"
+		case text == "+Inf":
+			text = "inf"
+		case text == "-Inf":
+			text = "-inf"
+		case !strings.ContainsAny(text, ".eE"):
+			// keep floats from being read back as integers
+			text += ".0"
+		}
+	}
+	return printer.colorize(text, n.colorcode), nil
+}
@@ -0,0 +1,179 @@
+package projson
+
+import (
+	"testing"
+	"time"
+)
+
+const tomlTestDoc = `{"title": "example", "a b": 1.5, "owner": {"name": "alice", "dob": {"year": 1979}}, "deep": {"er": {"v": 1}}, "fruit": [{"name": "apple", "physical": {"color": "red"}, "variety": [{"name": "fuji"}, {"name": "gala"}]}], "points": [[1, 2], {"x": 3}], "empty": {}, "none": []}`
+
+func TestTOMLFormat(t *testing.T) {
+	expected := `title = "example"
+"a b" = 1.5
+points = [[1, 2], { x = 3 }]
+empty = {}
+none = []
+
+[owner]
+name = "alice"
+
+[owner.dob]
+year = 1979
+
+[deep.er]
+v = 1
+
+[[fruit]]
+name = "apple"
+
+[fruit.physical]
+color = "red"
+
+[[fruit.variety]]
+name = "fuji"
+
+[[fruit.variety]]
+name = "gala"
+`
+
+	jp := NewPrinter()
+	jp.SetFormat(TOMLFormat)
+	if err := putJSON(jp, tomlTestDoc); err != nil {
+		t.Fatal(err)
+	}
+
+	if actual, err := jp.String(); err != nil || expected != actual {
+		t.Errorf("expected: %v\nactual: %v (%v)", expected, actual, err)
+	}
+}
+
+func TestTOMLFloat(t *testing.T) {
+	jp := NewPrinter()
+	jp.SetFormat(TOMLFormat)
+	jp.Obj().Key("a").Float(100).Key("b").Float(0.25).Key("c").FloatFmt(3, "%.2e").End()
+
+	expected := "a = 100.0\nb = 0.25\nc = 3.00e+00\n"
+	if actual, err := jp.String(); err != nil || expected != actual {
+		t.Errorf("expected: %v\nactual: %v (%v)", expected, actual, err)
+	}
+}
+
+func TestTOMLStrict(t *testing.T) {
+	cases := []struct {
+		src     string
+		strict  bool
+		success bool
+	}{
+		{`{"a": [1, 2]}`, true, true},
+		{`{"a": [[1], ["x"]]}`, true, true},
+		{`{"a": [1, "x"]}`, true, false},
+		{`{"a": [1, 2.5]}`, true, false},
+		{`{"a": [[1], {"x": 1}]}`, true, false},
+		{`{"a": {"b": [[1, "x"]]}}`, true, false},
+		{`{"a": [1, "x"]}`, false, true},
+	}
+
+	for _, c := range cases {
+		jp := NewPrinter()
+		jp.SetFormat(TOMLFormat)
+		jp.SetTOMLStrict(c.strict)
+		err := putJSON(jp, c.src)
+		if success := err == nil; success != c.success {
+			t.Errorf("%s (strict: %v)\nexpected: %v\nactual: %v", c.src, c.strict, c.success, err)
+		}
+	}
+}
+
+func TestTOMLColoredValues(t *testing.T) {
+	// colors other than those of the kinds do not change the types
+	jp := NewPrinter()
+	jp.SetFormat(TOMLFormat)
+	jp.PutValue(struct {
+		X float64 `projson:"x,color=red"`
+	}{3})
+
+	expected := "x = 3.0\n"
+	if actual, err := jp.String(); err != nil || expected != actual {
+		t.Errorf("expected: %v\nactual: %v (%v)", expected, actual, err)
+	}
+
+	cases := []struct {
+		values  []func(jp *JsonPrinter) error
+		success bool
+	}{
+		{[]func(jp *JsonPrinter) error{
+			func(jp *JsonPrinter) error { return jp.PutInt(1) },
+			func(jp *JsonPrinter) error { return jp.PutDuration(2 * time.Second) },
+		}, true},
+		{[]func(jp *JsonPrinter) error{
+			func(jp *JsonPrinter) error { return jp.PutDuration(time.Second) },
+			func(jp *JsonPrinter) error { return jp.PutDuration(1500 * time.Millisecond) },
+		}, false},
+	}
+
+	for i, c := range cases {
+		jp := NewPrinter()
+		jp.SetFormat(TOMLFormat)
+		jp.SetTOMLStrict(true)
+		jp.BeginObject()
+		jp.PutKey("a")
+		jp.BeginArray()
+		for _, put := range c.values {
+			put(jp)
+		}
+		jp.FinishArray()
+		jp.FinishObject()
+		if _, err := jp.String(); (err == nil) != c.success {
+			t.Errorf("case %d\nexpected: %v\nactual: %v", i, c.success, err)
+		}
+	}
+}
+
+func TestTOMLUnrepresentable(t *testing.T) {
+	jp := NewPrinter()
+	jp.SetFormat(TOMLFormat)
+	if err := jp.PutInt(1); err == nil {
+		t.Errorf("top-level scalar must be an error")
+	}
+	if _, err := jp.String(); err == nil {
+		t.Errorf("error must be sticky")
+	}
+
+	jp = NewPrinter()
+	jp.SetFormat(TOMLFormat)
+	if err := jp.PutObject(map[string]interface{}{"a": nil}); err == nil {
+		t.Errorf("null must be an error")
+	}
+
+	jp = NewPrinter()
+	jp.SetFormat(TOMLFormat)
+	jp.BeginArray()
+	if err := jp.FinishArray(); err == nil {
+		t.Errorf("top-level array must be an error")
+	}
+
+	jp = NewPrinter()
+	jp.SetFormat(TOMLFormat)
+	jp.PutObject(map[string]interface{}{"a": 1})
+	if err := jp.PutObject(map[string]interface{}{"b": 1}); err == nil {
+		t.Errorf("second document must be an error")
+	}
+}
+
+func TestTOMLASCIIOnly(t *testing.T) {
+	jp := NewPrinter()
+	jp.SetFormat(TOMLFormat)
+	jp.SetASCIIOnly(true)
+	jp.SetJSON5Options(JSON5SingleQuotes)
+	jp.BeginObject()
+	jp.PutKey("e")
+	jp.PutString("\U0001F600é")
+	jp.PutKey("k\U0001F600")
+	jp.PutInt(1)
+	jp.FinishObject()
+
+	expected := "e = \"\\U0001f600\\u00e9\"\n\"k\\U0001f600\" = 1\n"
+	if actual, err := jp.String(); err != nil || expected != actual {
+		t.Errorf("expected: %v\nactual: %v (%v)", expected, actual, err)
+	}
+}
@@ -0,0 +1,238 @@
+package projson
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// formats of SetTimeFormat
+const (
+	TimeRFC3339 int = iota // strings in RFC 3339, with fractional seconds if any
+	TimeUnix               // seconds since the Unix epoch
+	TimeLayout             // strings in the layout given to SetTimeLayout
+)
+
+// formats of SetDurationFormat
+const (
+	DurationSeconds int = iota // seconds, e.g. 90.5
+	DurationString             // as time.Duration.String, e.g. "1m30.5s"
+)
+
+// formats of SetBytesFormat
+const (
+	BytesBase64    int = iota // strings in standard base64
+	BytesBase64URL            // strings in URL-safe base64
+	BytesHex                  // strings in lowercase hexadecimal
+)
+
+func (printer *JsonPrinter) SetTimeFormat(format int) error {
+	if printer.err != nil {
+		return printer.err
+	}
+
+	if printer.state != stateInit {
+		printer.err = errors.New("Time format cannot changed after putting some items")
+		return printer.err
+	}
+
+	printer.timefmt = format
+	return nil
+}
+
+// SetTimeLayout makes PutTime format times by the layout of time.Format.
+func (printer *JsonPrinter) SetTimeLayout(layout string) error {
+	if err := printer.SetTimeFormat(TimeLayout); err != nil {
+		return err
+	}
+
+	printer.timelayout = layout
+	return nil
+}
+
+func (printer *JsonPrinter) SetDurationFormat(format int) error {
+	if printer.err != nil {
+		return printer.err
+	}
+
+	if printer.state != stateInit {
+		printer.err = errors.New("Duration format cannot changed after putting some items")
+		return printer.err
+	}
+
+	printer.durationfmt = format
+	return nil
+}
+
+func (printer *JsonPrinter) SetBytesFormat(format int) error {
+	if printer.err != nil {
+		return printer.err
+	}
+
+	if printer.state != stateInit {
+		printer.err = errors.New("Bytes format cannot changed after putting some items")
+		return printer.err
+	}
+
+	printer.bytesfmt = format
+	return nil
+}
+
+// putColored puts s in the color colorcode. The literal of strings is
+// made from their value.
+func (printer *JsonPrinter) putColored(s Scalar, colorcode int) error {
+	if printer.err != nil {
+		return printer.err
+	}
+
+	if s.Kind == StringScalar {
+		b := s.bytes
+		var err error
+		if s, err = printer.stringScalar(s.Value.(string)); err != nil {
+			return err
+		}
+		s.bytes = b
+	}
+	s.color = colorcode
+	return printer.putScalar(s)
+}
+
+func (printer *JsonPrinter) PutTime(t time.Time) error {
+	return printer.putTime(t, colorTime)
+}
+
+func (printer *JsonPrinter) putTime(t time.Time, colorcode int) error {
+	switch printer.timefmt {
+	case TimeUnix:
+		sec, nsec := t.Unix(), int64(t.Nanosecond())
+		if sec < 0 && nsec > 0 {
+			// -1.25 is -2 seconds and 750ms
+			return printer.putColored(secondsScalar(true, uint64(-(sec+1)), uint64(1e9-nsec)), colorcode)
+		}
+		if sec < 0 {
+			return printer.putColored(secondsScalar(true, uint64(-sec), 0), colorcode)
+		}
+		return printer.putColored(secondsScalar(false, uint64(sec), uint64(nsec)), colorcode)
+	case TimeLayout:
+		return printer.putColored(Scalar{Kind: StringScalar, Value: t.Format(printer.timelayout)}, colorcode)
+	}
+	return printer.putColored(Scalar{Kind: StringScalar, Value: t.Format(time.RFC3339Nano)}, colorcode)
+}
+
+func (printer *JsonPrinter) PutDuration(d time.Duration) error {
+	return printer.putDuration(d, colorDuration)
+}
+
+func (printer *JsonPrinter) putDuration(d time.Duration, colorcode int) error {
+	if printer.durationfmt == DurationString {
+		return printer.putColored(Scalar{Kind: StringScalar, Value: d.String()}, colorcode)
+	}
+
+	// as unsigned, for the magnitude of math.MinInt64
+	u := uint64(d)
+	if d < 0 {
+		u = -u
+	}
+	return printer.putColored(secondsScalar(d < 0, u/1e9, u%1e9), colorcode)
+}
+
+// secondsScalar returns the exact decimal number of sec seconds and nsec
+// nanoseconds, negated if neg.
+func secondsScalar(neg bool, sec uint64, nsec uint64) Scalar {
+	str := strconv.FormatUint(sec, 10)
+	if nsec > 0 {
+		str += "." + strings.TrimRight(strconv.FormatUint(nsec+1e9, 10)[1:], "0")
+	}
+	if neg && (sec > 0 || nsec > 0) {
+		str = "-" + str
+	}
+
+	if nsec == 0 {
+		v := int64(sec)
+		if neg {
+			v = -v
+		}
+		return Scalar{Kind: IntScalar, Literal: str, Value: v}
+	}
+
+	f, _ := strconv.ParseFloat(str, 64)
+	return Scalar{Kind: FloatScalar, Literal: str, Value: f}
+}
+
+// PutBytes puts b as a string in the format set by SetBytesFormat, or
+// null if b is nil. CBORFormat and MessagePackFormat write b as binary.
+func (printer *JsonPrinter) PutBytes(b []byte) error {
+	return printer.putBytes(b, colorBytes)
+}
+
+func (printer *JsonPrinter) putBytes(b []byte, colorcode int) error {
+	if b == nil {
+		return printer.PutNull()
+	}
+
+	var str string
+	switch printer.bytesfmt {
+	case BytesBase64URL:
+		str = base64.URLEncoding.EncodeToString(b)
+	case BytesHex:
+		str = hex.EncodeToString(b)
+	default:
+		str = base64.StdEncoding.EncodeToString(b)
+	}
+	return printer.putColored(Scalar{Kind: StringScalar, Value: str, bytes: b}, colorcode)
+}
+
+// PutIP puts ip as a string, or null if it is nil.
+func (printer *JsonPrinter) PutIP(ip net.IP) error {
+	return printer.putIP(ip, colorIP)
+}
+
+func (printer *JsonPrinter) putIP(ip net.IP, colorcode int) error {
+	if ip == nil {
+		return printer.PutNull()
+	}
+
+	if printer.err != nil {
+		return printer.err
+	}
+
+	if len(ip) != net.IPv4len && len(ip) != net.IPv6len {
+		printer.err = errors.New("Invalid IP address of length " + strconv.Itoa(len(ip)))
+		return printer.err
+	}
+	return printer.putColored(Scalar{Kind: StringScalar, Value: ip.String()}, colorcode)
+}
+
+// PutURL puts u as a string, or null if it is nil.
+func (printer *JsonPrinter) PutURL(u *url.URL) error {
+	return printer.putURL(u, colorURL)
+}
+
+func (printer *JsonPrinter) putURL(u *url.URL, colorcode int) error {
+	if u == nil {
+		return printer.PutNull()
+	}
+	return printer.putColored(Scalar{Kind: StringScalar, Value: u.String()}, colorcode)
+}
+
+// PutUUID puts uuid as a string in the canonical form, e.g.
+// "f47ac10b-58cc-4372-a567-0e02b2c3d479". UUID types of [16]byte, like
+// that of github.com/google/uuid, can be given as they are.
+func (printer *JsonPrinter) PutUUID(uuid [16]byte) error {
+	var buf [36]byte
+	hex.Encode(buf[0:8], uuid[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], uuid[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], uuid[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], uuid[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], uuid[10:])
+	return printer.putColored(Scalar{Kind: StringScalar, Value: string(buf[:])}, colorUUID)
+}
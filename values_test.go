@@ -0,0 +1,208 @@
+package projson
+
+import (
+	"math"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestPutTime(t *testing.T) {
+	loc := time.FixedZone("JST", 9*60*60)
+	cases := []struct {
+		format   int
+		t        time.Time
+		expected string
+	}{
+		{TimeRFC3339, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), `"2024-01-02T03:04:05Z"`},
+		{TimeRFC3339, time.Date(2024, 1, 2, 3, 4, 5, 500000000, loc), `"2024-01-02T03:04:05.5+09:00"`},
+		{TimeUnix, time.Unix(1700000000, 0), `1700000000`},
+		{TimeUnix, time.Unix(1700000000, 123456789), `1700000000.123456789`},
+		{TimeUnix, time.Unix(-2, 750000000), `-1.25`},
+		{TimeUnix, time.Unix(0, -500000000), `-0.5`},
+		{TimeUnix, time.Unix(-3, 0), `-3`},
+	}
+
+	for _, c := range cases {
+		jp := NewPrinter()
+		jp.SetTimeFormat(c.format)
+		jp.PutTime(c.t)
+		if actual, err := jp.String(); err != nil || actual != c.expected {
+			t.Errorf("expected: %v\nactual: %v %v", c.expected, actual, err)
+		}
+	}
+
+	jp := NewPrinter()
+	jp.SetTimeLayout("2006/01/02")
+	jp.PutTime(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+	if actual, _ := jp.String(); actual != `"2024/01/02"` {
+		t.Errorf("expected: %v\nactual: %v", `"2024/01/02"`, actual)
+	}
+}
+
+func TestPutDuration(t *testing.T) {
+	cases := []struct {
+		format   int
+		d        time.Duration
+		expected string
+	}{
+		{DurationSeconds, 90 * time.Second, `90`},
+		{DurationSeconds, 90*time.Second + 500*time.Millisecond, `90.5`},
+		{DurationSeconds, -time.Millisecond, `-0.001`},
+		{DurationSeconds, math.MinInt64, `-9223372036.854775808`},
+		{DurationString, 90*time.Second + 500*time.Millisecond, `"1m30.5s"`},
+	}
+
+	for _, c := range cases {
+		jp := NewPrinter()
+		jp.SetDurationFormat(c.format)
+		jp.PutDuration(c.d)
+		if actual, err := jp.String(); err != nil || actual != c.expected {
+			t.Errorf("expected: %v\nactual: %v %v", c.expected, actual, err)
+		}
+	}
+}
+
+func TestPutBytes(t *testing.T) {
+	b := []byte{0xfb, 0xff, 0x01}
+	cases := []struct {
+		format   int
+		expected string
+	}{
+		{BytesBase64, `["+/8B",null]`},
+		{BytesBase64URL, `["-_8B",null]`},
+		{BytesHex, `["fbff01",null]`},
+	}
+
+	for _, c := range cases {
+		jp := NewPrinter()
+		jp.SetBytesFormat(c.format)
+		jp.BeginArray()
+		jp.PutBytes(b)
+		jp.PutBytes(nil)
+		jp.FinishArray()
+		if actual, err := jp.String(); err != nil || actual != c.expected {
+			t.Errorf("expected: %v\nactual: %v %v", c.expected, actual, err)
+		}
+	}
+}
+
+func TestPutNetValues(t *testing.T) {
+	u, _ := url.Parse("https://example.com/a b?q=1")
+
+	jp := NewPrinter()
+	jp.BeginArray()
+	jp.PutIP(net.IPv4(192, 168, 0, 1))
+	jp.PutIP(net.ParseIP("2001:db8::1"))
+	jp.PutIP(nil)
+	jp.PutURL(u)
+	jp.PutURL(nil)
+	jp.PutUUID([16]byte{0xf4, 0x7a, 0xc1, 0x0b, 0x58, 0xcc, 0x43, 0x72, 0xa5, 0x67, 0x0e, 0x02, 0xb2, 0xc3, 0xd4, 0x79})
+	jp.FinishArray()
+
+	expected := `["192.168.0.1","2001:db8::1",null,"https://example.com/a%20b?q=1",null,"f47ac10b-58cc-4372-a567-0e02b2c3d479"]`
+	if actual, err := jp.String(); err != nil || actual != expected {
+		t.Errorf("expected: %v\nactual: %v %v", expected, actual, err)
+	}
+
+	jp = NewPrinter()
+	if err := jp.PutIP(net.IP{1, 2}); err == nil {
+		t.Errorf("expected: error\nactual: nil")
+	}
+}
+
+func TestValueColors(t *testing.T) {
+	type uuid [16]byte
+
+	jp := NewPrinter()
+	jp.SetColor(true)
+	jp.SetDurationFormat(DurationString)
+	jp.BeginArray()
+	jp.PutTime(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+	jp.PutDuration(time.Second)
+	jp.PutBytes([]byte("hi"))
+	jp.PutIP(net.IPv4(127, 0, 0, 1))
+	jp.PutURL(&url.URL{Scheme: "http", Host: "a"})
+	jp.PutUUID(uuid{})
+	jp.FinishArray()
+
+	expected := "[" + color(`"2024-01-02T03:04:05Z"`, colorTime) + "," +
+		color(`"1s"`, colorDuration) + "," +
+		color(`"aGk="`, colorBytes) + "," +
+		color(`"127.0.0.1"`, colorIP) + "," +
+		color(`"http://a"`, colorURL) + "," +
+		color(`"00000000-0000-0000-0000-000000000000"`, colorUUID) + "]"
+	if actual, _ := jp.String(); actual != expected {
+		t.Errorf("expected: %v\nactual: %v", expected, actual)
+	}
+
+	jp = NewPrinter()
+	jp.SetColor(true)
+	jp.SetColorMode(HTMLColor)
+	jp.PutDuration(1500 * time.Millisecond)
+	expected = `<span class="json-duration">1.5</span>`
+	if actual, _ := jp.String(); actual != expected {
+		t.Errorf("expected: %v\nactual: %v", expected, actual)
+	}
+}
+
+func TestPutValueTypes(t *testing.T) {
+	u, _ := url.Parse("http://a/")
+	jp := NewPrinter()
+	jp.SetBytesFormat(BytesHex)
+	jp.PutValue(struct {
+		At      time.Time
+		Elapsed time.Duration
+		Raw     []byte
+		Addr    net.IP
+		Link    *url.URL
+	}{time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), 2 * time.Second, []byte{1}, net.IPv6loopback, u})
+
+	expected := `{"At":"2024-01-02T00:00:00Z","Elapsed":2,"Raw":"01","Addr":"::1","Link":"http://a/"}`
+	if actual, err := jp.String(); err != nil || actual != expected {
+		t.Errorf("expected: %v\nactual: %v %v", expected, actual, err)
+	}
+}
+
+func TestPutValueTypeOptions(t *testing.T) {
+	jp := NewPrinter()
+	jp.SetColor(true)
+	jp.PutValue(struct {
+		T time.Time       `projson:"t,color=red"`
+		D []time.Duration `projson:"d,color=green"`
+		B []byte          `projson:"b,color=blue"`
+		A *net.IP         `projson:"a,color=cyan"`
+	}{time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), []time.Duration{time.Second}, []byte{1}, &net.IPv6loopback})
+
+	expected := "{" + color(`"t"`, colorKey) + ":" + color(`"2024-01-02T00:00:00Z"`, colorRed) + "," +
+		color(`"d"`, colorKey) + ":[" + color("1", colorGreen) + "]," +
+		color(`"b"`, colorKey) + ":" + color(`"AQ=="`, colorBlue) + "," +
+		color(`"a"`, colorKey) + ":" + color(`"::1"`, colorCyan) + "}"
+	if actual, err := jp.String(); err != nil || actual != expected {
+		t.Errorf("expected: %v\nactual: %v %v", expected, actual, err)
+	}
+
+	for _, v := range []interface{}{
+		struct {
+			T time.Time `projson:"t,string"`
+		}{},
+		struct {
+			D time.Duration `projson:"d,string"`
+		}{},
+		struct {
+			D time.Duration `projson:"d,fmt=%.1f"`
+		}{},
+		struct {
+			U url.URL `projson:"u,fmt=%.1f"`
+		}{},
+		struct {
+			B []byte `projson:"b,string"`
+		}{},
+	} {
+		jp := NewPrinter()
+		if err := jp.PutValue(v); err == nil {
+			t.Errorf("%T\nexpected: error\nactual: nil", v)
+		}
+	}
+}
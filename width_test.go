@@ -0,0 +1,77 @@
+package projson
+
+import "testing"
+
+func TestDisplayWidth(t *testing.T) {
+	cases := []struct {
+		str      string
+		expected int
+	}{
+		{"", 0},
+		{"hello", 5},
+		{`"quote"`, 7},
+		{"日本語", 6},
+		{"ｶﾀｶﾅ", 4},                 // halfwidth katakana
+		{"ＡＢ", 4},                   // fullwidth latin
+		{"한국어", 6},                  // precomposed hangul
+		{"\u1100\u1161", 2},         // conjoining hangul jamo
+		{"e\u0301", 1},              // combining acute accent
+		{"\U0001F600", 2},           // grinning face
+		{"\u2764", 1},               // heavy black heart, text presentation
+		{"\u2764\ufe0f", 2},         // heavy black heart, emoji presentation
+		{"\U0001F44D\U0001F3FD", 2}, // thumbs up with skin tone
+		{"\U0001F468\u200d\U0001F469\u200d\U0001F467", 2}, // family ZWJ sequence
+		{"\U0001F1EF\U0001F1F5", 2},                       // flag
+		{"\U0001F1EF\U0001F1F5\U0001F1FA\U0001F1F8", 4},   // two flags
+		{"\033[31mred\033[0m", 3},
+		{color("日本", colorString), 4},
+		{"a\tb\n", 2},
+	}
+
+	for _, c := range cases {
+		if actual := displayWidth(c.str); actual != c.expected {
+			t.Errorf("%q\nexpected: %v\nactual: %v", c.str, c.expected, actual)
+		}
+	}
+}
+
+func TestWideCharsSmartStyle(t *testing.T) {
+	jp := NewPrinter()
+
+	jp.SetStyle(SmartStyle)
+	jp.SetTermWidth(22)
+
+	jp.BeginArray()
+	jp.PutString("日本語")
+	jp.PutString("テキスト")
+	jp.PutString("改行")
+	jp.FinishArray()
+
+	expected := `["日本語", "テキスト",
+ "改行"]`
+	actual, _ := jp.String()
+
+	if expected != actual {
+		t.Errorf("expected: %v\nactual: %v", expected, actual)
+	}
+
+	// colour escapes must not affect wrapping
+	jp.Reset()
+	jp.SetStyle(SmartStyle)
+	jp.SetTermWidth(22)
+	jp.SetColor(true)
+
+	jp.BeginArray()
+	jp.PutString("日本語")
+	jp.PutString("テキスト")
+	jp.PutString("改行")
+	jp.FinishArray()
+
+	expected = "[" + color(`"日本語"`, colorString) + ", " + color(`"テキスト"`, colorString) + ",\n " +
+		color(`"改行"`, colorString) + "]"
+	actual, _ = jp.String()
+
+	if expected != actual {
+		t.Errorf("expected: %v\nactual: %v", expected, actual)
+	}
+}
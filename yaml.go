@@ -0,0 +1,175 @@
+package projson
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// YAMLFormat puts each top-level value as a YAML document. Containers are
+// written in block style, and in flow style from the nesting depth set by
+// SetYAMLFlowLevel:
+//
+//	key1: val1
+//	key2:
+//	  - 1
+//	  - key3: [4, 5]
+//
+// Strings are plain unless they would be read back as something else
+// (e.g. yes, 1e3, ~), in which case they are double-quoted.
+
+const yamlIndent = "  "
+
+func (printer *JsonPrinter) renderYAML(n *node) {
+	if printer.docs > 0 {
+		printer.buffer.WriteString("---\n")
+	}
+	printer.buffer.WriteString(printer.yamlBlock(n, "", 0))
+}
+
+// colorize colors text if the printer is in color mode.
+func (printer *JsonPrinter) colorize(text string, colorcode int) string {
+	if printer.color {
+		return printer.paint(text, colorcode)
+	}
+	return text
+}
+
+// yamlFlowed reports whether n at nesting depth is written in flow style.
+func (printer *JsonPrinter) yamlFlowed(n *node, depth int) bool {
+	return n.kind == nodeScalar || len(n.children) == 0 ||
+		(printer.yamlflow >= 0 && depth >= printer.yamlflow)
+}
+
+// yamlBlock returns n at nesting depth as lines, all but the first of
+// which are prefixed with ind.
+func (printer *JsonPrinter) yamlBlock(n *node, ind string, depth int) string {
+	if n.kind == nodeScalar {
+		return printer.yamlScalar(n, false) + "\n"
+	}
+	if printer.yamlFlowed(n, depth) {
+		return printer.yamlFlow(n) + "\n"
+	}
+
+	text := ""
+	for i, child := range n.children {
+		if i > 0 {
+			text += ind
+		}
+
+		if n.kind == nodeArray {
+			text += "- " + printer.yamlBlock(child, ind+yamlIndent, depth+1)
+			continue
+		}
+
+		text += printer.yamlKey(child.name, false) + ":"
+		if printer.yamlFlowed(child, depth+1) {
+			text += " " + printer.yamlBlock(child, ind, depth+1)
+		} else {
+			text += "\n" + ind + yamlIndent + printer.yamlBlock(child, ind+yamlIndent, depth+1)
+		}
+	}
+	return text
+}
+
+// yamlFlow returns n in flow style.
+func (printer *JsonPrinter) yamlFlow(n *node) string {
+	if n.kind == nodeScalar {
+		return printer.yamlScalar(n, true)
+	}
+
+	opener, closer := n.brackets()
+	text := opener
+	for i, child := range n.children {
+		if i > 0 {
+			text += ", "
+		}
+		if n.kind == nodeObject {
+			text += printer.yamlKey(child.name, true) + ": "
+		}
+		text += printer.yamlFlow(child)
+	}
+	return text + closer
+}
+
+// yamlKey returns key as a plain or double-quoted YAML key.
+func (printer *JsonPrinter) yamlKey(key string, flow bool) string {
+	if !printer.yamlPlainable(key, flow) {
+		key, _ = printer.quoteLong(key)
+	}
+	return printer.colorize(key, colorKey)
+}
+
+func (printer *JsonPrinter) yamlScalar(n *node, flow bool) string {
+	text := n.literal
+	switch {
+	case n.scalar == StringScalar:
+		// YAML escapes characters beyond the BMP as \UXXXXXXXX
+		if s, ok := n.value.(string); ok && printer.yamlPlainable(s, flow) {
+			text = s
+		} else if ok {
+			text, _ = printer.quoteLong(s)
+		}
+	case text == "NaN":
+		text = ".nan"
+	case text == "+Inf":
+		text = ".inf"
+	case text == "-Inf":
+		text = "-.inf"
+	}
+	return printer.colorize(text, n.colorcode)
+}
+
+// yamlPlainable reports whether s is put as a plain scalar, which it is
+// not if SetASCIIOnly would escape some of it.
+func (printer *JsonPrinter) yamlPlainable(s string, flow bool) bool {
+	if printer.asciiOnly {
+		for i := 0; i < len(s); i++ {
+			if s[i] >= utf8.RuneSelf {
+				return false
+			}
+		}
+	}
+	return yamlPlain(s, flow)
+}
+
+// yamlPlain reports whether s can be written as a plain scalar and read
+// back as the same string, under both YAML 1.1 and 1.2.
+func yamlPlain(s string, flow bool) bool {
+	if s == "" || s != strings.TrimSpace(s) {
+		return false
+	}
+
+	switch strings.ToLower(s) {
+	case "~", "null", "true", "false", "yes", "no", "on", "off", "y", "n",
+		".inf", "-.inf", "+.inf", ".nan", "<<", "=":
+		return false
+	}
+
+	// numbers, dates and times, in any notation
+	if s[0] >= '0' && s[0] <= '9' {
+		return false
+	}
+	if len(s) > 1 && strings.ContainsRune("+-.", rune(s[0])) &&
+		(s[1] == '.' || (s[1] >= '0' && s[1] <= '9')) {
+		return false
+	}
+
+	// indicators, including document markers
+	if strings.ContainsRune("-?:,[]{}#&*!|>'\"%@`", rune(s[0])) {
+		return false
+	}
+	if strings.Contains(s, ": ") || strings.Contains(s, " #") || strings.HasSuffix(s, ":") {
+		return false
+	}
+	if flow && strings.ContainsAny(s, ",[]{}") {
+		return false
+	}
+
+	for _, r := range s {
+		if r != ' ' && !unicode.IsPrint(r) {
+			return false
+		}
+	}
+	return true
+}
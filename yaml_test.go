@@ -0,0 +1,196 @@
+package projson
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const yamlTestDoc = `{"name": "alice", "yes": "no", "t": true, "null": null, "list": [1, 2.5, "a, b", [3, 4], {"k": "v", "k2": ["on"]}, [], {}], "obj": {"x": {"y": "z: w"}}, "empty": ""}`
+
+func TestYAMLFormat(t *testing.T) {
+	cases := []struct {
+		level    int
+		expected string
+	}{
+		{-1, `name: alice
+"yes": "no"
+t: true
+"null": null
+list:
+  - 1
+  - 2.5
+  - a, b
+  - - 3
+    - 4
+  - k: v
+    k2:
+      - "on"
+  - []
+  - {}
+obj:
+  x:
+    "y": "z: w"
+empty: ""
+`},
+		{1, `name: alice
+"yes": "no"
+t: true
+"null": null
+list: [1, 2.5, "a, b", [3, 4], {k: v, k2: ["on"]}, [], {}]
+obj: {x: {"y": "z: w"}}
+empty: ""
+`},
+		{0, `{name: alice, "yes": "no", t: true, "null": null, list: [1, 2.5, "a, b", [3, 4], {k: v, k2: ["on"]}, [], {}], obj: {x: {"y": "z: w"}}, empty: ""}
+`},
+	}
+
+	for _, c := range cases {
+		jp := NewPrinter()
+		jp.SetFormat(YAMLFormat)
+		jp.SetYAMLFlowLevel(c.level)
+		if err := putJSON(jp, yamlTestDoc); err != nil {
+			t.Fatal(err)
+		}
+
+		actual, _ := jp.String()
+		if c.expected != actual {
+			t.Errorf("flow level %d\nexpected: %v\nactual: %v", c.level, c.expected, actual)
+		}
+	}
+}
+
+func TestYAMLMultiDocument(t *testing.T) {
+	jp := NewPrinter()
+	jp.SetFormat(YAMLFormat)
+
+	jp.PutObject(map[string]interface{}{"a": 1})
+	jp.PutString("second")
+	jp.PutFloat(math.Inf(-1))
+	jp.PutArray([]interface{}{})
+
+	expected := "a: 1\n---\nsecond\n---\n-.inf\n---\n[]\n"
+	if actual, err := jp.String(); err != nil || expected != actual {
+		t.Errorf("expected: %v\nactual: %v (%v)", expected, actual, err)
+	}
+}
+
+func TestYAMLPlain(t *testing.T) {
+	cases := []struct {
+		s     string
+		plain bool
+	}{
+		{"alice", true},
+		{"a b", true},
+		{"a-b:c", true},
+		{"日本語", true},
+		{"", false},
+		{" a", false},
+		{"a ", false},
+		{"~", false},
+		{"null", false},
+		{"Yes", false},
+		{"NO", false},
+		{"off", false},
+		{"y", false},
+		{"1e3", false},
+		{"0x1F", false},
+		{"-1", false},
+		{".5", false},
+		{".inf", false},
+		{"-.Inf", false},
+		{"1_000", false},
+		{"12:30:00", false},
+		{"2001-12-14", false},
+		{"---", false},
+		{"...", false},
+		{"- a", false},
+		{"#a", false},
+		{"*a", false},
+		{"'a'", false},
+		{"a: b", false},
+		{"a #b", false},
+		{"a:", false},
+		{"a\nb", false},
+		{"a\tb", false},
+	}
+
+	for _, c := range cases {
+		if actual := yamlPlain(c.s, false); actual != c.plain {
+			t.Errorf("%q\nexpected: %v\nactual: %v", c.s, c.plain, actual)
+		}
+	}
+
+	if !yamlPlain("a,b", false) || yamlPlain("a,b", true) || yamlPlain("[a]", true) {
+		t.Errorf("flow indicators are not quoted in flow style only")
+	}
+}
+
+func TestYAMLColor(t *testing.T) {
+	jp := NewPrinter()
+	jp.SetFormat(YAMLFormat)
+	jp.SetColor(true)
+
+	jp.BeginObject()
+	jp.PutKey("k")
+	jp.BeginArray()
+	jp.PutInt(1)
+	jp.PutString("yes")
+	jp.FinishArray()
+	jp.FinishObject()
+
+	expected := color("k", colorKey) + ":\n  - " + color("1", colorInt) + "\n  - " + color(`"yes"`, colorString) + "\n"
+	if actual, _ := jp.String(); expected != actual {
+		t.Errorf("expected: %v\nactual: %v", expected, actual)
+	}
+}
+
+func TestYAMLColoredString(t *testing.T) {
+	jp := NewPrinter()
+	jp.SetFormat(YAMLFormat)
+	jp.BeginArray()
+	jp.PutValue(struct {
+		Level string `projson:"level,color=yellow"`
+	}{"warn"})
+	jp.PutTime(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC))
+	jp.FinishArray()
+
+	expected := "- level: warn\n- \"2024-01-02T00:00:00Z\"\n"
+	if actual, err := jp.String(); err != nil || expected != actual {
+		t.Errorf("expected: %v\nactual: %v (%v)", expected, actual, err)
+	}
+}
+
+func TestYAMLASCIIOnly(t *testing.T) {
+	cases := []struct {
+		ascii    bool
+		expected string
+	}{
+		{false, "k\U0001F600: \"\U0001F600: é\"\n"},
+		{true, "\"k\\U0001f600\": \"\\U0001f600: \\u00e9\"\n"},
+	}
+
+	for _, c := range cases {
+		jp := NewPrinter()
+		jp.SetFormat(YAMLFormat)
+		jp.SetASCIIOnly(c.ascii)
+		jp.BeginObject()
+		jp.PutKey("k\U0001F600")
+		jp.PutString("\U0001F600: é")
+		jp.FinishObject()
+
+		actual, err := jp.String()
+		if err != nil || actual != c.expected {
+			t.Errorf("expected: %v\nactual: %v (%v)", c.expected, actual, err)
+			continue
+		}
+
+		// these double-quoted YAML scalars read back as Go strings
+		value := strings.TrimSuffix(actual[strings.LastIndex(actual, ": \"")+2:], "\n")
+		if s, err := strconv.Unquote(value); err != nil || s != "\U0001F600: é" {
+			t.Errorf("expected: %v\nactual: %v (%v)", "\U0001F600: é", s, err)
+		}
+	}
+}